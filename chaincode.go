@@ -6,9 +6,9 @@ package main
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/erc20/controller"
+	"github.com/erc20/repository"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
 )
@@ -25,35 +25,166 @@ func NewChaincode() *ERC20Chaincode {
 }
 
 // Init is called when the chaincode is instantiated by the blockchain network.
-// params - tokenName, symbol, owner(address), amount
+// params - tokenName, symbol, owner(address), amount, [decimals], [cap]
 func (cc *ERC20Chaincode) Init(stub shim.ChaincodeStubInterface) sc.Response {
 	_, params := stub.GetFunctionAndParameters()
 	fmt.Println("Init called with params: ", params)
 
-	return cc.controller.Init(stub, params)
+	return emitEvents(stub, func(eventBuffer shim.ChaincodeStubInterface) sc.Response {
+		return cc.controller.Init(eventBuffer, params)
+	})
+}
+
+// pausableFunctions are the functions which cannot be called while the token is paused
+// every function which moves tokens into circulation, out of it or between balances is paused;
+// payouts of tokens already held by vesting, lock & HTLC records (release, revokeVesting, claimLocked,
+// htlcClaim & htlcRefund) are not paused, so a pause cannot make a timelock pass and break a swap in progress
+// (their recipients are still checked against frozen & blacklisted accounts)
+var pausableFunctions = map[string]bool{
+	"transfer":          true,
+	"batchTransfer":     true,
+	"transferFrom":      true,
+	"approve":           true,
+	"increaseAllowance": true,
+	"decreaseAllowance": true,
+	"mint":              true,
+	"burn":              true,
+	"burnFrom":          true,
+	"wipeFrozen":        true,
+	"permit":            true,
+	"createVesting":     true,
+	"transferWithLock":  true,
+	"htlcLock":          true,
+	"offerSwap":         true,
+	"swap":              true,
 }
 
 // Invoke is called as a result of an application request to run the chaincode.
 func (cc *ERC20Chaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
 	fcn, params := stub.GetFunctionAndParameters()
 
+	// check the token is not paused
+	if pausableFunctions[fcn] {
+		paused, err := repository.IsPaused(stub)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if paused {
+			return shim.Error("token is paused")
+		}
+	}
+
+	return emitEvents(stub, func(eventBuffer shim.ChaincodeStubInterface) sc.Response {
+		return cc.invoke(eventBuffer, fcn, params)
+	})
+}
+
+// emitEvents collects every event of run & emits them together at the end
+// nothing is emitted when run fails
+func emitEvents(stub shim.ChaincodeStubInterface, run func(shim.ChaincodeStubInterface) sc.Response) sc.Response {
+	eventBuffer := repository.NewEventBuffer(stub)
+	response := run(eventBuffer)
+	if response.GetStatus() >= 400 {
+		return response
+	}
+
+	err := eventBuffer.Flush()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return response
+}
+
+// invoke calls the controller function of fcn
+func (cc *ERC20Chaincode) invoke(stub shim.ChaincodeStubInterface, fcn string, params []string) sc.Response {
 	switch fcn {
 	case "totalSupply":
 		return cc.controller.TotalSupply(stub, params)
+	case "decimals":
+		return cc.controller.Decimals(stub, params)
+	case "cap":
+		return cc.controller.Cap(stub, params)
+	case "setCap":
+		return cc.controller.SetCap(stub, params)
+	case "metadata":
+		return cc.controller.Metadata(stub, params)
 	case "balanceOf":
 		return cc.controller.BalanceOf(stub, params)
+	case "balanceOfAt":
+		return cc.controller.BalanceOfAt(stub, params)
+	case "totalSupplyAt":
+		return cc.controller.TotalSupplyAt(stub, params)
+	case "snapshot":
+		return cc.controller.Snapshot(stub, params)
+	case "createVesting":
+		return cc.controller.CreateVesting(stub, params)
+	case "release":
+		return cc.controller.Release(stub, params)
+	case "revokeVesting":
+		return cc.controller.RevokeVesting(stub, params)
+	case "vestingSchedules":
+		return cc.controller.VestingSchedules(stub, params)
+	case "transferWithLock":
+		return cc.controller.TransferWithLock(stub, params)
+	case "claimLocked":
+		return cc.controller.ClaimLocked(stub, params)
+	case "lockedBalanceOf":
+		return cc.controller.LockedBalanceOf(stub, params)
+	case "htlcLock":
+		return cc.controller.HTLCLock(stub, params)
+	case "htlcClaim":
+		return cc.controller.HTLCClaim(stub, params)
+	case "htlcRefund":
+		return cc.controller.HTLCRefund(stub, params)
+	case "htlc":
+		return cc.controller.HTLC(stub, params)
+	case "holders":
+		return cc.controller.Holders(stub, params)
+	case "holderCount":
+		return cc.controller.HolderCount(stub, params)
+	case "transferHistory":
+		return cc.controller.TransferHistory(stub, params)
 	case "transfer":
 		return cc.controller.Transfer(stub, params)
+	case "batchTransfer":
+		return cc.controller.BatchTransfer(stub, params)
 	case "allowance":
 		return cc.controller.Allowance(stub, params)
 	case "approve":
 		return cc.controller.Approve(stub, params)
+	case "revoke":
+		return cc.controller.Revoke(stub, params)
+	case "revokeAll":
+		return cc.controller.RevokeAll(stub, params)
+	case "registerPermitKey":
+		return cc.controller.RegisterPermitKey(stub, params)
+	case "permit":
+		return cc.controller.Permit(stub, params)
+	case "permitDomain":
+		return cc.controller.PermitDomain(stub, params)
+	case "nonces":
+		return cc.controller.Nonces(stub, params)
 	case "approvalList":
 		return cc.controller.ApprovalList(stub, params)
 	case "transferFrom":
 		return cc.controller.TransferFrom(stub, params)
 	case "transferOtherToken":
 		return cc.controller.TransferOtherToken(stub, params)
+	case "offerSwap":
+		return cc.controller.OfferSwap(stub, params)
+	case "cancelSwapOffer":
+		return cc.controller.CancelSwapOffer(stub, params)
+	case "swapOffer":
+		return cc.controller.SwapOffer(stub, params)
+	case "swap":
+		return cc.controller.Swap(stub, params)
+	case "addTrustedChaincode":
+		return cc.controller.AddTrustedChaincode(stub, params)
+	case "removeTrustedChaincode":
+		return cc.controller.RemoveTrustedChaincode(stub, params)
+	case "trustedChaincodes":
+		return cc.controller.TrustedChaincodes(stub, params)
 	case "increaseAllowance":
 		return cc.controller.IncreaseAllowance(stub, params)
 	case "decreaseAllowance":
@@ -62,114 +193,53 @@ func (cc *ERC20Chaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
 		return cc.controller.Mint(stub, params)
 	case "burn":
 		return cc.controller.Burn(stub, params)
+	case "burnFrom":
+		return cc.controller.BurnFrom(stub, params)
+	case "grantRole":
+		return cc.controller.GrantRole(stub, params)
+	case "revokeRole":
+		return cc.controller.RevokeRole(stub, params)
+	case "hasRole":
+		return cc.controller.HasRole(stub, params)
+	case "roleMembers":
+		return cc.controller.RoleMembers(stub, params)
+	case "pause":
+		return cc.controller.Pause(stub, params)
+	case "unpause":
+		return cc.controller.Unpause(stub, params)
+	case "paused":
+		return cc.controller.Paused(stub, params)
+	case "freeze":
+		return cc.controller.Freeze(stub, params)
+	case "unfreeze":
+		return cc.controller.Unfreeze(stub, params)
+	case "blacklist":
+		return cc.controller.Blacklist(stub, params)
+	case "unblacklist":
+		return cc.controller.Unblacklist(stub, params)
+	case "wipeFrozen":
+		return cc.controller.WipeFrozen(stub, params)
+	case "isFrozen":
+		return cc.controller.IsFrozen(stub, params)
+	case "isBlacklisted":
+		return cc.controller.IsBlacklisted(stub, params)
+	case "migrateKeys":
+		return cc.controller.MigrateKeys(stub, params)
+	case "setBalanceMode":
+		return cc.controller.SetBalanceMode(stub, params)
+	case "balanceMode":
+		return cc.controller.BalanceMode(stub, params)
+	case "compactBalance":
+		return cc.controller.CompactBalance(stub, params)
 	case "transactionAPI":
-		return cc.transactionAPI(stub, params)
-	case "putDummyData":
-		return cc.putDummyData(stub, params)
+		return cc.controller.TransactionAPI(stub, params)
 	case "stateDataAPI":
-		return cc.stateDataAPI(stub, params)
+		return cc.controller.StateDataAPI(stub, params)
 	case "stateDataAPI2":
-		return cc.stateDataAPI2(stub, params)
+		return cc.controller.StateDataAPI2(stub, params)
 	case "historyAPI":
-		return cc.historyAPI(stub, params)
+		return cc.controller.HistoryAPI(stub, params)
 	default:
 		return sc.Response{Status: 404, Message: "404 Not Found", Payload: nil}
 	}
 }
-
-// <Transaction API>
-//   - GetTxID
-//   - GetTxTimestamp()
-//   - GetCreator()
-//   - GetSignedProposal()
-// <State data API>
-//   - GetStateByRange()
-//   - GetStateByRangeWithPagination()()
-//   - GetStateByPartialCompositeKeyWithPagination()
-// <Key API>
-//   - GetHistoryForKey()
-
-func (cc *ERC20Chaincode) transactionAPI(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-
-	// GetTxID
-	fmt.Println("==================== TX ID ====================")
-	txID := stub.GetTxID()
-	fmt.Println(txID)
-	fmt.Println()
-
-	// GetTxTimestamp
-	fmt.Println("==================== TX Timestamp ====================")
-	txTimeStamp, _ := stub.GetTxTimestamp()
-	fmt.Println(txTimeStamp.String())
-	fmt.Println()
-
-	// GetCreator
-	fmt.Println("==================== Creator ====================")
-	creator, _ := stub.GetCreator()
-	fmt.Println(string(creator))
-	fmt.Println()
-
-	// GetSignedProposal
-	fmt.Println("==================== Signed Proposal ====================")
-	signedProposal, _ := stub.GetSignedProposal()
-	fmt.Println(signedProposal.String())
-	fmt.Println()
-
-	return shim.Success(nil)
-}
-
-const title = "stateTest"
-
-func (cc *ERC20Chaincode) putDummyData(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	for i := 1; i <= 30; i++ {
-		stub.PutState(title+strconv.Itoa(i), []byte("this is test - "+strconv.Itoa(i)))
-	}
-
-	return shim.Success(nil)
-}
-
-func (cc *ERC20Chaincode) stateDataAPI(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-
-	startKey, endKey := params[0], params[1]
-
-	iterator, _ := stub.GetStateByRange(startKey, endKey)
-	for iterator.HasNext() {
-		kv, _ := iterator.Next()
-		fmt.Println("===== " + kv.GetKey() + " ======")
-		fmt.Println(string(kv.GetValue()))
-		fmt.Println()
-	}
-
-	return shim.Success(nil)
-}
-
-func (cc *ERC20Chaincode) stateDataAPI2(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-
-	startKey, endKey, bookMark := params[0], params[1], params[2]
-
-	iterator, res, _ := stub.GetStateByRangeWithPagination(startKey, endKey, 5, bookMark)
-	for iterator.HasNext() {
-		kv, _ := iterator.Next()
-		fmt.Println("===== " + kv.GetKey() + " ======")
-		fmt.Println(string(kv.GetValue()))
-		fmt.Println()
-	}
-	fmt.Println("========== Book Mark==========")
-	fmt.Println(res.GetBookmark())
-	fmt.Println()
-
-	return shim.Success(nil)
-}
-
-func (cc *ERC20Chaincode) historyAPI(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	key := params[0]
-	iterator, _ := stub.GetHistoryForKey(key)
-	for iterator.HasNext() {
-		km, _ := iterator.Next()
-		fmt.Println("===== " + km.GetTimestamp().String() + " =====")
-		fmt.Println(string(km.GetValue()))
-		fmt.Println()
-	}
-
-	return shim.Success(nil)
-}
@@ -5,13 +5,34 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math"
+	"math/big"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	"github.com/erc20/identity"
 	"github.com/erc20/model"
 	"github.com/erc20/repository"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	"github.com/hyperledger/fabric/protos/msp"
+	sc "github.com/hyperledger/fabric/protos/peer"
 )
 
 var function = []byte("mint")
@@ -31,16 +52,32 @@ func Test_Init_success(t *testing.T) {
 	}
 
 	// check totalSupply
-	erc20 := model.ERC20Metadata{}
-	erc20Bytes, _ := stub.GetState(tokenName)
-	json.Unmarshal(erc20Bytes, &erc20)
-	if *erc20.GetTotalSupply() != initAmount {
+	erc20, _ := repository.GetERC20Metadata(stub, tokenName)
+	if erc20.GetTotalSupply().Cmp(big.NewInt(initAmount)) != 0 {
 		t.FailNow()
 	}
 
 	// check dappcampus balance
 	balance, _ := repository.GetBalance(stub, address, true)
-	if *balance != initAmount {
+	if balance.Cmp(big.NewInt(initAmount)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_Init_upgradeKeepsState_success(t *testing.T) {
+	stub := initERC20(t)
+
+	// upgrade cannot reset supply, balance or cap
+	res := stub.MockInit("2", [][]byte{[]byte("init"), []byte(tokenName), []byte("dt"), []byte(address), []byte("5"), []byte("18"), []byte("5")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	erc20, _ := repository.GetERC20Metadata(stub, tokenName)
+	if erc20.GetTotalSupply().Cmp(big.NewInt(initAmount)) != 0 || erc20.GetCap() != nil {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, address, true)
+	if balance.Cmp(big.NewInt(initAmount)) != 0 {
 		t.FailNow()
 	}
 }
@@ -65,46 +102,1317 @@ func Test_Mint_lengthIsInvalid_failure(t *testing.T) {
 }
 
 func Test_Mint_amountIsNotPositive_failure(t *testing.T) {
-	stub := initERC20(t)
-	arguments := [][]byte{function, []byte(tokenName), []byte(address), []byte("-100")}
-	arguments2 := [][]byte{function, []byte(tokenName), []byte(address), []byte("abcde")}
-	res := stub.MockInvoke(txMint, arguments)
-	res2 := stub.MockInvoke(txMint, arguments2)
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	arguments := [][]byte{function, []byte(tokenName), []byte(ownerAddress), []byte("-100")}
+	arguments2 := [][]byte{function, []byte(tokenName), []byte(ownerAddress), []byte("abcde")}
+	res := invokeAs(stub, owner, txMint, arguments)
+	res2 := invokeAs(stub, owner, txMint, arguments2)
 
-	if res.Status != shim.ERROR && res2.Status != shim.ERROR {
+	if res.Status != shim.ERROR || res2.Status != shim.ERROR {
 		t.FailNow()
 	}
 }
 
 func Test_Mint_success(t *testing.T) {
-	stub := initERC20(t)
+	stub, owner, ownerAddress := initERC20WithOwner(t)
 	const increaseAmount = 10000
-	arguments := [][]byte{function, []byte(tokenName), []byte(address), []byte(strconv.Itoa(increaseAmount))}
-	res := stub.MockInvoke(txMint, arguments)
+	arguments := [][]byte{function, []byte(tokenName), []byte(ownerAddress), []byte(strconv.Itoa(increaseAmount))}
+	res := invokeAs(stub, owner, txMint, arguments)
 	if res.Status != shim.OK {
 		t.FailNow()
 	}
 
 	// increase TotalSupply
 	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenName)
-	if *totalSupply != initAmount+increaseAmount {
+	if totalSupply.Cmp(big.NewInt(initAmount+increaseAmount)) != 0 {
 		t.FailNow()
 	}
 
 	// increase owner balance
-	balance, _ := repository.GetBalance(stub, address, true)
-	if *balance != initAmount+increaseAmount {
+	balance, _ := repository.GetBalance(stub, ownerAddress, true)
+	if balance.Cmp(big.NewInt(initAmount+increaseAmount)) != 0 {
 		t.FailNow()
 	}
 
 	// emit trasnfer event
-	data := <-stub.ChaincodeEventsChannel
-	if data.GetEventName() != repository.TransferEventKey {
+	events := nextEvents(t, stub)
+	if len(events) != 1 || events[0].Type != repository.TransferEventKey {
 		t.FailNow()
 	}
-	event := model.NewTransferEvent("admin", address, increaseAmount)
+	event := model.NewTransferEvent("admin", ownerAddress, big.NewInt(increaseAmount))
 	eventBytes, _ := json.Marshal(event)
-	if string(data.GetPayload()) != string(eventBytes) {
+	if string(events[0].Payload) != string(eventBytes) {
+		t.FailNow()
+	}
+}
+
+func Test_Mint_callerIsNotMinter_failure(t *testing.T) {
+	stub, _, _ := initERC20WithOwner(t)
+	creator, creatorAddress := newCreator(t)
+	arguments := [][]byte{function, []byte(tokenName), []byte(creatorAddress), []byte("100")}
+	res := invokeAs(stub, creator, txMint, arguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_GrantRole_success(t *testing.T) {
+	stub, owner, _ := initERC20WithOwner(t)
+	minter, minterAddress := newCreator(t)
+
+	// grant minter role
+	res := invokeAs(stub, owner, "txGrant", [][]byte{[]byte("grantRole"), []byte(model.MinterRole), []byte(minterAddress)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// minter can mint
+	arguments := [][]byte{function, []byte(tokenName), []byte(minterAddress), []byte("100")}
+	res = invokeAs(stub, minter, txMint, arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// revoke minter role
+	res = invokeAs(stub, owner, "txRevoke", [][]byte{[]byte("revokeRole"), []byte(model.MinterRole), []byte(minterAddress)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = invokeAs(stub, minter, txMint, arguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+// identityStub is MockStub which returns the creator & args of the test transaction
+// (MockStub.GetCreator is not implemented)
+type identityStub struct {
+	*shim.MockStub
+	creator []byte
+	args    [][]byte
+}
+
+func (stub *identityStub) GetCreator() ([]byte, error) {
+	return stub.creator, nil
+}
+
+func (stub *identityStub) GetArgs() [][]byte {
+	return stub.args
+}
+
+func (stub *identityStub) GetStringArgs() []string {
+	strargs := make([]string, 0, len(stub.args))
+	for _, barg := range stub.args {
+		strargs = append(strargs, string(barg))
+	}
+	return strargs
+}
+
+func (stub *identityStub) GetFunctionAndParameters() (string, []string) {
+	allargs := stub.GetStringArgs()
+	return allargs[0], allargs[1:]
+}
+
+// newCreator returns serialized identity of new X.509 certificate & address of the identity
+func newCreator(t *testing.T) ([]byte, string) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.FailNow()
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "user"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		t.FailNow()
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	creator, err := proto.Marshal(&msp.SerializedIdentity{Mspid: "Org1MSP", IdBytes: certPEM})
+	if err != nil {
+		t.FailNow()
+	}
+
+	stub := &identityStub{MockStub: shim.NewMockStub("identity", nil), creator: creator}
+	creatorAddress, err := identity.GetAddress(stub)
+	if err != nil {
+		t.FailNow()
+	}
+
+	return creator, creatorAddress
+}
+
+// invokeAs invokes the chaincode as creator
+func invokeAs(stub *shim.MockStub, creator []byte, txID string, args [][]byte) sc.Response {
+	return invokeAtAs(stub, creator, txID, time.Now(), args)
+}
+
+// invokeAtAs invokes the chaincode as creator with the transaction time txTime
+func invokeAtAs(stub *shim.MockStub, creator []byte, txID string, txTime time.Time, args [][]byte) sc.Response {
+	stub.MockTransactionStart(txID)
+	defer stub.MockTransactionEnd(txID)
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: txTime.Unix()}
+	return NewChaincode().Invoke(&identityStub{MockStub: stub, creator: creator, args: args})
+}
+
+// nextEvents returns the events of the next envelope event
+func nextEvents(t *testing.T, stub *shim.MockStub) []model.Event {
+	data := <-stub.ChaincodeEventsChannel
+	if data.GetEventName() != repository.EnvelopeEventKey {
+		t.FailNow()
+	}
+	envelope := model.EventEnvelope{}
+	if err := json.Unmarshal(data.GetPayload(), &envelope); err != nil {
+		t.FailNow()
+	}
+	return envelope.Events
+}
+
+// initERC20WithOwner initializes the token with new identity as owner
+func initERC20WithOwner(t *testing.T) (*shim.MockStub, []byte, string) {
+	owner, ownerAddress := newCreator(t)
+	stub := shim.NewMockStub("erc20", NewChaincode())
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenName), []byte("dt"), []byte(ownerAddress), []byte(strconv.Itoa(initAmount))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// role granted events of Init
+	if events := nextEvents(t, stub); len(events) != len(model.Roles) || events[0].Type != repository.RoleGrantedEventKey {
+		t.FailNow()
+	}
+	return stub, owner, ownerAddress
+}
+
+func Test_Transfer_callerIsNotCreator_failure(t *testing.T) {
+	stub := initERC20(t)
+	creator, _ := newCreator(t)
+	arguments := [][]byte{[]byte("transfer"), []byte(address), []byte("recipient"), []byte("100")}
+	res := invokeAs(stub, creator, "txTransfer", arguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// balance is not changed
+	balance, _ := repository.GetBalance(stub, address, true)
+	if balance.Cmp(big.NewInt(initAmount)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_Transfer_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+
+	const transferAmount = 100
+	arguments := [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte(address), []byte(strconv.Itoa(transferAmount))}
+	res := invokeAs(stub, owner, "txTransfer", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	callerBalance, _ := repository.GetBalance(stub, ownerAddress, true)
+	recipientBalance, _ := repository.GetBalance(stub, address, true)
+	if callerBalance.Cmp(big.NewInt(initAmount-transferAmount)) != 0 || recipientBalance.Cmp(big.NewInt(transferAmount)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_Burn_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	const burnAmount = 1000
+	arguments := [][]byte{[]byte("burn"), []byte(tokenName), []byte(ownerAddress), []byte(strconv.Itoa(burnAmount))}
+	res := invokeAs(stub, owner, "txBurn", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// decrease TotalSupply & owner balance
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenName)
+	balance, _ := repository.GetBalance(stub, ownerAddress, true)
+	if totalSupply.Cmp(big.NewInt(initAmount-burnAmount)) != 0 || balance.Cmp(big.NewInt(initAmount-burnAmount)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_Burn_balanceIsNotSufficient_failure(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	arguments := [][]byte{[]byte("burn"), []byte(tokenName), []byte(ownerAddress), []byte(strconv.Itoa(initAmount + 1))}
+	res := invokeAs(stub, owner, "txBurn", arguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_Mint_overflow_failure(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	maxAmount := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	arguments := [][]byte{function, []byte(tokenName), []byte(ownerAddress), []byte(maxAmount.String())}
+	res := invokeAs(stub, owner, txMint, arguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// TotalSupply is not changed
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenName)
+	if totalSupply.Cmp(big.NewInt(initAmount)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_Mint_largeAmountIsDecimalString_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	// 2^53 + 1 cannot be read from JSON number without losing precision
+	largeAmount := new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 53), big.NewInt(1))
+	arguments := [][]byte{function, []byte(tokenName), []byte(ownerAddress), []byte(largeAmount.String())}
+	res := invokeAs(stub, owner, txMint, arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// amount of transfer event is decimal string
+	events := nextEvents(t, stub)
+	if len(events) != 1 || !strings.Contains(string(events[0].Payload), `"amount":"9007199254740993"`) {
+		t.FailNow()
+	}
+
+	// totalSupply is decimal string
+	totalSupply := new(big.Int).Add(largeAmount, big.NewInt(initAmount))
+	res = stub.MockInvoke("txQuery", [][]byte{[]byte("totalSupply"), []byte(tokenName)})
+	if res.Status != shim.OK || string(res.Payload) != strconv.Quote(totalSupply.String()) {
+		t.FailNow()
+	}
+
+	// amount saved as number before is still read
+	amount := model.Amount{}
+	if err := json.Unmarshal([]byte("500"), &amount); err != nil || amount.Int().Cmp(big.NewInt(500)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_Decimals_success(t *testing.T) {
+	stub := initERC20(t)
+	res := stub.MockInvoke("txDecimals", [][]byte{[]byte("decimals"), []byte(tokenName)})
+	if res.Status != shim.OK || string(res.Payload) != "18" {
+		t.FailNow()
+	}
+}
+
+func Test_Balance_addressIsTokenName_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+
+	// transfer to the address same as token name
+	arguments := [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte(tokenName), []byte("100")}
+	res := invokeAs(stub, owner, "txTransfer", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// token metadata is not overwritten
+	erc20, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil || erc20.GetTotalSupply().Cmp(big.NewInt(initAmount)) != 0 {
+		t.FailNow()
+	}
+}
+
+// openRangeStub treats empty endKey of range query as the end of keys like the peer does
+// (MockStub compares the keys with empty endKey)
+type openRangeStub struct {
+	*shim.MockStub
+}
+
+func (stub *openRangeStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if endKey == "" && startKey != "" {
+		endKey = string(utf8.MaxRune)
+	}
+	return stub.MockStub.GetStateByRange(startKey, endKey)
+}
+
+func Test_MigrateKeys_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+
+	// save token metadata & balance under flat keys
+	const legacyToken = "legacyToken"
+	stub.MockTransactionStart("txLegacy")
+	const legacyAddress = "00112233445566778899aabbccddeeff00112233"
+	stub.PutState(legacyToken, []byte(`{"name":"legacyToken","symbol":"lt","owner":"legacy","totalSupply":500}`))
+	stub.PutState(legacyAddress, []byte("500"))
+	stub.PutState("stateTest1", []byte("this is test - 1"))
+	stub.PutState("decimals", []byte("18"))
+	stub.MockTransactionEnd("txLegacy")
+
+	// legacy address already received token under the composite key
+	res := invokeAs(stub, owner, "txTransfer", [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte(legacyAddress), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// migrate 2 flat keys per transaction
+	rangeStub := &openRangeStub{MockStub: stub}
+	result := model.MigrationResult{}
+	for i, bookmark := 0, ""; i == 0 || bookmark != ""; i++ {
+		rangeStub.MockTransactionStart("txMigrate" + strconv.Itoa(i))
+		page, err := repository.MigrateFlatKeys(rangeStub, 2, bookmark)
+		rangeStub.MockTransactionEnd("txMigrate" + strconv.Itoa(i))
+		if err != nil || len(page.Metadata)+len(page.Balances)+len(page.Skipped) > 2 {
+			t.FailNow()
+		}
+		result.Metadata = append(result.Metadata, page.Metadata...)
+		result.Balances = append(result.Balances, page.Balances...)
+		result.Skipped = append(result.Skipped, page.Skipped...)
+		bookmark = page.Bookmark
+	}
+	if len(result.Metadata) != 1 || len(result.Balances) != 1 || len(result.Skipped) != 2 {
+		t.FailNow()
+	}
+
+	// metadata & balance are moved (balance is added to the saved balance)
+	erc20, err := repository.GetERC20Metadata(stub, legacyToken)
+	if err != nil || erc20.GetTotalSupply().Cmp(big.NewInt(500)) != 0 {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, legacyAddress, true)
+	if balance.Cmp(big.NewInt(600)) != 0 {
+		t.FailNow()
+	}
+	if legacyBytes, _ := stub.GetState(legacyAddress); legacyBytes != nil {
+		t.FailNow()
+	}
+
+	// the other flat keys are not changed, even if the value is a number
+	if dummyBytes, _ := stub.GetState("stateTest1"); dummyBytes == nil {
+		t.FailNow()
+	}
+	if decimalsBytes, _ := stub.GetState("decimals"); string(decimalsBytes) != "18" {
+		t.FailNow()
+	}
+
+	// saved metadata is not overwritten
+	stub.MockTransactionStart("txLegacy2")
+	stub.PutState(tokenName, []byte(`{"name":"dappToken","symbol":"dt","owner":"legacy","totalSupply":1}`))
+	stub.MockTransactionEnd("txLegacy2")
+	res = invokeAs(stub, owner, "txMigrateAgain", [][]byte{[]byte("migrateKeys"), []byte("10")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_Pause_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	transferArguments := [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte(address), []byte("100")}
+
+	// pause
+	res := invokeAs(stub, owner, "txPause", [][]byte{[]byte("pause")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	events := nextEvents(t, stub)
+	if len(events) != 1 || events[0].Type != repository.PausedEventKey {
+		t.FailNow()
+	}
+
+	// transfer is blocked but balanceOf works
+	res = invokeAs(stub, owner, "txTransfer", transferArguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+	res = stub.MockInvoke("txBalanceOf", [][]byte{[]byte("balanceOf"), []byte(ownerAddress)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// unpause
+	res = invokeAs(stub, owner, "txUnpause", [][]byte{[]byte("unpause")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = invokeAs(stub, owner, "txTransfer", transferArguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_Pause_callerIsNotPauser_failure(t *testing.T) {
+	stub, _, _ := initERC20WithOwner(t)
+	creator, _ := newCreator(t)
+	res := invokeAs(stub, creator, "txPause", [][]byte{[]byte("pause")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_Freeze_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+
+	// freeze recipient
+	res := invokeAs(stub, owner, "txFreeze", [][]byte{[]byte("freeze"), []byte(address)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	events := nextEvents(t, stub)
+	if len(events) != 1 || events[0].Type != repository.FrozenEventKey {
+		t.FailNow()
+	}
+
+	// frozen account cannot receive
+	res = invokeAs(stub, owner, "txTransfer", [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte(address), []byte("100")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+	res = invokeAs(stub, owner, txMint, [][]byte{function, []byte(tokenName), []byte(address), []byte("100")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_WipeFrozen_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	const transferAmount = 100
+
+	res := invokeAs(stub, owner, "txTransfer", [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte(address), []byte(strconv.Itoa(transferAmount))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// account must be frozen before wipe
+	wipeArguments := [][]byte{[]byte("wipeFrozen"), []byte(tokenName), []byte(address)}
+	res = invokeAs(stub, owner, "txWipe", wipeArguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	res = invokeAs(stub, owner, "txFreeze", [][]byte{[]byte("freeze"), []byte(address)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// wipe burns token, so it is blocked while paused
+	res = invokeAs(stub, owner, "txPause", [][]byte{[]byte("pause")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = invokeAs(stub, owner, "txWipe", wipeArguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+	res = invokeAs(stub, owner, "txUnpause", [][]byte{[]byte("unpause")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	res = invokeAs(stub, owner, "txWipe", wipeArguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// balance is burned
+	balance, _ := repository.GetBalance(stub, address, true)
+	totalSupply, _ := repository.GetERC20TotalSupply(stub, tokenName)
+	if balance.Sign() != 0 || totalSupply.Cmp(big.NewInt(initAmount-transferAmount)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_TransferFrom_emitsEveryEvent_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	spender, spenderAddress := newCreator(t)
+
+	res := invokeAs(stub, owner, "txApprove", [][]byte{[]byte("approve"), []byte(ownerAddress), []byte(spenderAddress), []byte("300")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	nextEvents(t, stub)
+
+	arguments := [][]byte{[]byte("transferFrom"), []byte(ownerAddress), []byte(spenderAddress), []byte(address), []byte("100")}
+	res = invokeAs(stub, spender, "txTransferFrom", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// transfer & approval events are emitted in order
+	events := nextEvents(t, stub)
+	if len(events) != 2 || events[0].Type != repository.TransferEventKey || events[1].Type != repository.ApprovalEventKey {
+		t.FailNow()
+	}
+}
+
+func Test_TransferFrom_wholeAllowance_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	spender, spenderAddress := newCreator(t)
+	const allowance = 300
+
+	res := invokeAs(stub, owner, "txApprove", [][]byte{[]byte("approve"), []byte(ownerAddress), []byte(spenderAddress), []byte(strconv.Itoa(allowance))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// spend the whole allowance
+	arguments := [][]byte{[]byte("transferFrom"), []byte(ownerAddress), []byte(spenderAddress), []byte(address), []byte(strconv.Itoa(allowance))}
+	res = invokeAs(stub, spender, "txTransferFrom", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	remaining, _ := repository.GetAllowance(stub, ownerAddress, spenderAddress)
+	balance, _ := repository.GetBalance(stub, address, true)
+	if remaining.Sign() != 0 || balance.Cmp(big.NewInt(allowance)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_TransferFrom_allowanceIsNotSufficient_failure(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	spender, spenderAddress := newCreator(t)
+
+	res := invokeAs(stub, owner, "txApprove", [][]byte{[]byte("approve"), []byte(ownerAddress), []byte(spenderAddress), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	arguments := [][]byte{[]byte("transferFrom"), []byte(ownerAddress), []byte(spenderAddress), []byte(address), []byte("101")}
+	res = invokeAs(stub, spender, "txTransferFrom", arguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// balance is not moved
+	balance, _ := repository.GetBalance(stub, ownerAddress, true)
+	if balance.Cmp(big.NewInt(initAmount)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_Approve_zero_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	res := invokeAs(stub, owner, "txApprove", [][]byte{[]byte("approve"), []byte(ownerAddress), []byte(address), []byte("0")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+}
+
+func Test_RevokeAll_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	for _, spender := range []string{"spender1", "spender2"} {
+		res := invokeAs(stub, owner, "txApprove", [][]byte{[]byte("approve"), []byte(ownerAddress), []byte(spender), []byte("100")})
+		if res.Status != shim.OK {
+			t.FailNow()
+		}
+	}
+
+	// revoke one allowance
+	res := invokeAs(stub, owner, "txRevoke", [][]byte{[]byte("revoke"), []byte(ownerAddress), []byte("spender1")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	approvals, _ := repository.GetApprovalList(stub, ownerAddress)
+	if len(approvals) != 1 || approvals[0].Spender != "spender2" {
+		t.FailNow()
+	}
+
+	// revoke every allowance
+	res = invokeAs(stub, owner, "txRevokeAll", [][]byte{[]byte("revokeAll"), []byte(ownerAddress)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	approvals, _ = repository.GetApprovalList(stub, ownerAddress)
+	if len(approvals) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_Allowance_expired_failure(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	spender, spenderAddress := newCreator(t)
+	expiresAt := time.Now().Add(time.Hour)
+
+	arguments := [][]byte{[]byte("approve"), []byte(ownerAddress), []byte(spenderAddress), []byte("100"), []byte(strconv.FormatInt(expiresAt.Unix(), 10))}
+	res := invokeAs(stub, owner, "txApprove", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// allowance cannot be spent after expiry
+	afterExpiry := expiresAt.Add(time.Minute)
+	arguments = [][]byte{[]byte("transferFrom"), []byte(ownerAddress), []byte(spenderAddress), []byte(address), []byte("100")}
+	res = invokeAtAs(stub, spender, "txTransferFrom", afterExpiry, arguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// allowance is zero & reports expiry
+	res = invokeAtAs(stub, spender, "txAllowance", afterExpiry, [][]byte{[]byte("allowance"), []byte(ownerAddress), []byte(spenderAddress)})
+	allowance := model.AllowanceRecord{}
+	json.Unmarshal(res.Payload, &allowance)
+	if res.Status != shim.OK || allowance.Allowance.Int().Sign() != 0 || !allowance.Expired || allowance.ExpiresAt != expiresAt.Unix() {
+		t.FailNow()
+	}
+}
+
+func Test_Permit_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	relayer, _ := newCreator(t)
+
+	// register permit key of owner
+	signingKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	publicKeyDER, _ := x509.MarshalPKIXPublicKey(&signingKey.PublicKey)
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})
+	res := invokeAs(stub, owner, "txRegister", [][]byte{[]byte("registerPermitKey"), publicKeyPEM})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// permit domain is the txID of Init
+	res = invokeAs(stub, relayer, "txDomain", [][]byte{[]byte("permitDomain")})
+	domain := string(res.Payload)
+	if res.Status != shim.OK || domain != "1" {
+		t.FailNow()
+	}
+
+	// permit signed for other deployment or other token is rejected
+	deadline := time.Now().Add(time.Hour).Unix()
+	arguments := [][]byte{[]byte("permit"), []byte(tokenName), []byte(ownerAddress), []byte(address), []byte("500"),
+		[]byte(strconv.FormatInt(deadline, 10)), nil}
+	for _, message := range []*model.PermitMessage{
+		model.NewPermitMessage(stub.GetChannelID(), "otherInit", tokenName, "dt", ownerAddress, address, "500", 0, deadline),
+		model.NewPermitMessage(stub.GetChannelID(), domain, "otherToken", "ot", ownerAddress, address, "500", 0, deadline),
+	} {
+		digest, _ := message.Digest()
+		signature, _ := ecdsa.SignASN1(rand.Reader, signingKey, digest)
+		arguments[6] = []byte(base64.StdEncoding.EncodeToString(signature))
+		res = invokeAs(stub, relayer, "txOtherPermit", arguments)
+		if res.Status != shim.ERROR {
+			t.FailNow()
+		}
+	}
+
+	// sign permit message offline
+	message := model.NewPermitMessage(stub.GetChannelID(), domain, tokenName, "dt", ownerAddress, address, "500", 0, deadline)
+	digest, _ := message.Digest()
+	signature, _ := ecdsa.SignASN1(rand.Reader, signingKey, digest)
+	arguments[6] = []byte(base64.StdEncoding.EncodeToString(signature))
+
+	// relayer submits permit
+	res = invokeAs(stub, relayer, "txPermit", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	allowance, _ := repository.GetAllowance(stub, ownerAddress, address)
+	if allowance.Cmp(big.NewInt(500)) != 0 {
+		t.FailNow()
+	}
+
+	// permit cannot be replayed
+	res = invokeAs(stub, relayer, "txPermit", arguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_BatchTransfer_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	legs := `[{"recipient":"alice","amount":"100"},{"recipient":"bob","amount":"200"},{"recipient":"alice","amount":"300"}]`
+	res := invokeAs(stub, owner, "txBatch", [][]byte{[]byte("batchTransfer"), []byte(ownerAddress), []byte(legs)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	ownerBalance, _ := repository.GetBalance(stub, ownerAddress, true)
+	aliceBalance, _ := repository.GetBalance(stub, "alice", true)
+	bobBalance, _ := repository.GetBalance(stub, "bob", true)
+	if ownerBalance.Cmp(big.NewInt(initAmount-600)) != 0 || aliceBalance.Cmp(big.NewInt(400)) != 0 || bobBalance.Cmp(big.NewInt(200)) != 0 {
+		t.FailNow()
+	}
+
+	// transfer event per leg
+	if events := nextEvents(t, stub); len(events) != 3 {
+		t.FailNow()
+	}
+}
+
+func Test_BatchTransfer_balanceIsNotSufficient_failure(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	legs := `[{"recipient":"alice","amount":"100000"},{"recipient":"bob","amount":"1"}]`
+	res := invokeAs(stub, owner, "txBatch", [][]byte{[]byte("batchTransfer"), []byte(ownerAddress), []byte(legs)})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_DeltaBalance_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	merchant, merchantAddress := newCreator(t)
+	res := invokeAs(stub, owner, "txMode", [][]byte{[]byte("setBalanceMode"), []byte(merchantAddress), []byte("delta")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// credits are saved as delta keys
+	for _, txID := range []string{"txPay1", "txPay2"} {
+		res = invokeAs(stub, owner, txID, [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte(merchantAddress), []byte("100")})
+		if res.Status != shim.OK {
+			t.FailNow()
+		}
+	}
+	deltaKey, _ := stub.CreateCompositeKey("balanceDelta", []string{merchantAddress, "txPay2"})
+	if delta, _ := stub.GetState(deltaKey); string(delta) != "100" {
+		t.FailNow()
+	}
+	res = invokeAs(stub, owner, "txQuery", [][]byte{[]byte("balanceOf"), []byte(merchantAddress)})
+	merchantBalance := model.Balance{}
+	if err := json.Unmarshal(res.Payload, &merchantBalance); err != nil || merchantBalance.Balance.Int().Cmp(big.NewInt(200)) != 0 {
+		t.FailNow()
+	}
+
+	// debit is still read-verified
+	res = invokeAs(stub, merchant, "txOverdraft", [][]byte{[]byte("transfer"), []byte(merchantAddress), []byte(ownerAddress), []byte("201")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// only merchant or admin can compact
+	other, _ := newCreator(t)
+	res = invokeAs(stub, other, "txOtherCompact", [][]byte{[]byte("compactBalance"), []byte(merchantAddress)})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// compaction folds the deltas into the balance
+	res = invokeAs(stub, owner, "txCompact", [][]byte{[]byte("compactBalance"), []byte(merchantAddress)})
+	if res.Status != shim.OK || string(res.Payload) != "200" {
+		t.FailNow()
+	}
+	if delta, _ := stub.GetState(deltaKey); delta != nil {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, merchantAddress, true)
+	if balance.Cmp(big.NewInt(200)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_HolderCount_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	res := invokeAs(stub, owner, "txTransfer1", [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte("alice"), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = invokeAs(stub, owner, "txCount1", [][]byte{[]byte("holderCount")})
+	if string(res.Payload) != "2" {
+		t.FailNow()
+	}
+
+	// owner is removed from holders when balance is zero
+	res = invokeAs(stub, owner, "txTransfer2", [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte("alice"), []byte(strconv.Itoa(initAmount - 100))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = invokeAs(stub, owner, "txCount2", [][]byte{[]byte("holderCount")})
+	if string(res.Payload) != "1" {
+		t.FailNow()
+	}
+}
+
+func Test_TransactionAPI_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	res := invokeAs(stub, owner, "txInfo", [][]byte{[]byte("transactionAPI")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	txInfo := model.TransactionInfo{}
+	if err := json.Unmarshal(res.Payload, &txInfo); err != nil {
+		t.FailNow()
+	}
+	if txInfo.TxID != "txInfo" || txInfo.Address != ownerAddress || txInfo.Function != "transactionAPI" {
+		t.FailNow()
+	}
+}
+
+func Test_LedgerAPI_callerIsNotAdmin_failure(t *testing.T) {
+	stub, _, _ := initERC20WithOwner(t)
+	other, _ := newCreator(t)
+	res := invokeAs(stub, other, "txInfo", [][]byte{[]byte("transactionAPI")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+	res = invokeAs(stub, other, "txState", [][]byte{[]byte("stateDataAPI"), []byte("10"), []byte(""), []byte(""), []byte("")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// params are checked before they are used
+	res = invokeAs(stub, other, "txHistory", [][]byte{[]byte("historyAPI")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_Transfer_recordsActivity_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	res := invokeAtAs(stub, owner, "txMemo", time.Unix(1700000000, 0), [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte("alice"), []byte("100"), []byte("invoice 42")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// activity of recipient
+	activityKey, _ := stub.CreateCompositeKey("activity", []string{"alice", fmt.Sprintf("%020d", 1700000000), "txMemo", "1"})
+	activityBytes, _ := stub.GetState(activityKey)
+	activity := model.Activity{}
+	if err := json.Unmarshal(activityBytes, &activity); err != nil {
+		t.FailNow()
+	}
+	if activity.Direction != model.ActivityIn || activity.Counterparty != ownerAddress || activity.Amount != "100" || activity.Memo != "invoice 42" {
+		t.FailNow()
+	}
+
+	// activity of sender
+	activityKey, _ = stub.CreateCompositeKey("activity", []string{ownerAddress, fmt.Sprintf("%020d", 1700000000), "txMemo", "0"})
+	if activityBytes, _ = stub.GetState(activityKey); activityBytes == nil {
+		t.FailNow()
+	}
+}
+
+// historyStub returns the fixed history of keys (MockStub doesn't implement GetHistoryForKey)
+type historyStub struct {
+	*shim.MockStub
+	history map[string][]*queryresult.KeyModification
+}
+
+func (stub *historyStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{modifications: stub.history[key]}, nil
+}
+
+type historyIterator struct {
+	modifications []*queryresult.KeyModification
+}
+
+func (iterator *historyIterator) HasNext() bool {
+	return len(iterator.modifications) > 0
+}
+
+func (iterator *historyIterator) Next() (*queryresult.KeyModification, error) {
+	modification := iterator.modifications[0]
+	iterator.modifications = iterator.modifications[1:]
+	return modification, nil
+}
+
+func (iterator *historyIterator) Close() error {
+	return nil
+}
+
+func Test_GetBalanceAt_success(t *testing.T) {
+	stub := &historyStub{MockStub: shim.NewMockStub("erc20", NewChaincode())}
+	aliceKey, _ := stub.CreateCompositeKey("balance", []string{"alice"})
+	stub.history = map[string][]*queryresult.KeyModification{aliceKey: {
+		{TxId: "tx1", Value: []byte("100"), Timestamp: &timestamp.Timestamp{Seconds: 1000}},
+		{TxId: "tx2", Value: []byte("300"), Timestamp: &timestamp.Timestamp{Seconds: 2000}},
+		{TxId: "tx3", IsDelete: true, Timestamp: &timestamp.Timestamp{Seconds: 3000}},
+	}}
+
+	// last write at or before time
+	balance, err := repository.GetBalanceAt(stub, "alice", time.Unix(2500, 0))
+	if err != nil || balance.Value != "300" || balance.TxID != "tx2" {
+		t.FailNow()
+	}
+	balance, _ = repository.GetBalanceAt(stub, "alice", time.Unix(2000, 0))
+	if balance.Value != "300" {
+		t.FailNow()
+	}
+
+	// before first write & after delete
+	balance, _ = repository.GetBalanceAt(stub, "alice", time.Unix(500, 0))
+	if balance.Value != "0" || balance.TxID != "" {
+		t.FailNow()
+	}
+	balance, _ = repository.GetBalanceAt(stub, "alice", time.Unix(3500, 0))
+	if balance.Value != "0" || balance.TxID != "tx3" {
+		t.FailNow()
+	}
+
+	// balance in delta mode is refused
+	modeKey, _ := stub.CreateCompositeKey("balanceMode", []string{"alice"})
+	stub.history[modeKey] = []*queryresult.KeyModification{
+		{TxId: "tx4", Value: []byte("delta"), Timestamp: &timestamp.Timestamp{Seconds: 1500}},
+		{TxId: "tx5", IsDelete: true, Timestamp: &timestamp.Timestamp{Seconds: 2500}},
+	}
+	if _, err = repository.GetBalanceAt(stub, "alice", time.Unix(2000, 0)); err == nil {
+		t.FailNow()
+	}
+	if _, err = repository.GetBalanceAt(stub, "alice", time.Unix(3000, 0)); err != nil {
+		t.FailNow()
+	}
+}
+
+func Test_Snapshot_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	res := invokeAs(stub, owner, "txSnapshot1", [][]byte{[]byte("snapshot")})
+	if res.Status != shim.OK || string(res.Payload) != "1" {
+		t.FailNow()
+	}
+	nextEvents(t, stub)
+
+	// balance & total supply are changed after snapshot 1
+	res = invokeAs(stub, owner, "txTransfer", [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte("alice"), []byte("100")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	nextEvents(t, stub)
+	res = invokeAs(stub, owner, "txMint", [][]byte{[]byte("mint"), []byte(tokenName), []byte(ownerAddress), []byte("500")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	nextEvents(t, stub)
+	res = invokeAs(stub, owner, "txSnapshot2", [][]byte{[]byte("snapshot")})
+	if res.Status != shim.OK || string(res.Payload) != "2" {
+		t.FailNow()
+	}
+	nextEvents(t, stub)
+
+	expected := map[string]string{
+		"balanceOfAt/" + ownerAddress + "/1": strconv.Itoa(initAmount),
+		"balanceOfAt/alice/1":                "0",
+		"balanceOfAt/" + ownerAddress + "/2": strconv.Itoa(initAmount + 400),
+		"totalSupplyAt/" + tokenName + "/1":  strconv.Itoa(initAmount),
+		"totalSupplyAt/" + tokenName + "/2":  strconv.Itoa(initAmount + 500),
+	}
+	for query, value := range expected {
+		args := strings.Split(query, "/")
+		res = invokeAs(stub, owner, "txQuery", [][]byte{[]byte(args[0]), []byte(args[1]), []byte(args[2])})
+		result := model.HistoricalValue{}
+		if err := json.Unmarshal(res.Payload, &result); err != nil || result.Value != value {
+			t.FailNow()
+		}
+	}
+
+	// snapshot which doesn't exist
+	res = invokeAs(stub, owner, "txQuery", [][]byte{[]byte("balanceOfAt"), []byte(ownerAddress), []byte("3")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_Mint_capExceeded_failure(t *testing.T) {
+	owner, ownerAddress := newCreator(t)
+	stub := shim.NewMockStub("erc20", NewChaincode())
+	res := stub.MockInit("1", [][]byte{[]byte("init"), []byte(tokenName), []byte("dt"), []byte(ownerAddress), []byte(strconv.Itoa(initAmount)), []byte("18"), []byte(strconv.Itoa(initAmount + 1000))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	res = invokeAs(stub, owner, "txMint1", [][]byte{[]byte("mint"), []byte(tokenName), []byte(ownerAddress), []byte("1000")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = invokeAs(stub, owner, "txMint2", [][]byte{[]byte("mint"), []byte(tokenName), []byte(ownerAddress), []byte("1")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_SetCap_onlyLowered_success(t *testing.T) {
+	stub, owner, _ := initERC20WithOwner(t)
+	res := invokeAs(stub, owner, "txCap1", [][]byte{[]byte("setCap"), []byte(tokenName), []byte(strconv.Itoa(initAmount * 2))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// cap cannot be raised or be less than total supply
+	res = invokeAs(stub, owner, "txCap2", [][]byte{[]byte("setCap"), []byte(tokenName), []byte(strconv.Itoa(initAmount * 3))})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+	res = invokeAs(stub, owner, "txCap3", [][]byte{[]byte("setCap"), []byte(tokenName), []byte(strconv.Itoa(initAmount - 1))})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	res = invokeAs(stub, owner, "txQuery", [][]byte{[]byte("cap"), []byte(tokenName)})
+	if string(res.Payload) != strconv.Quote(strconv.Itoa(initAmount*2)) {
+		t.FailNow()
+	}
+}
+
+func Test_Vesting_release_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	beneficiary, beneficiaryAddress := newCreator(t)
+	res := invokeAtAs(stub, owner, "txVesting", time.Unix(1000, 0), [][]byte{[]byte("createVesting"), []byte(beneficiaryAddress), []byte("1000"),
+		[]byte("1000"), []byte("100"), []byte("1000"), []byte("true")})
+	if res.Status != shim.OK || string(res.Payload) != "txVesting" {
+		t.FailNow()
+	}
+	ownerBalance, _ := repository.GetBalance(stub, ownerAddress, true)
+	if ownerBalance.Cmp(big.NewInt(initAmount-1000)) != 0 {
+		t.FailNow()
+	}
+
+	// nothing is vested before cliff
+	res = invokeAtAs(stub, beneficiary, "txRelease1", time.Unix(1050, 0), [][]byte{[]byte("release"), []byte(beneficiaryAddress), []byte("txVesting")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// linear release after cliff
+	res = invokeAtAs(stub, beneficiary, "txRelease2", time.Unix(1250, 0), [][]byte{[]byte("release"), []byte(beneficiaryAddress), []byte("txVesting")})
+	if res.Status != shim.OK || string(res.Payload) != "250" {
+		t.FailNow()
+	}
+
+	// revocation returns unvested tokens to the creator
+	res = invokeAtAs(stub, owner, "txRevoke", time.Unix(1500, 0), [][]byte{[]byte("revokeVesting"), []byte(beneficiaryAddress), []byte("txVesting")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	ownerBalance, _ = repository.GetBalance(stub, ownerAddress, true)
+	if ownerBalance.Cmp(big.NewInt(initAmount-500)) != 0 {
+		t.FailNow()
+	}
+	res = invokeAtAs(stub, beneficiary, "txQuery", time.Unix(2500, 0), [][]byte{[]byte("vestingSchedules"), []byte(beneficiaryAddress)})
+	statuses := []model.VestingStatus{}
+	if err := json.Unmarshal(res.Payload, &statuses); err != nil || len(statuses) != 1 || statuses[0].Releasable.Int().Cmp(big.NewInt(250)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_CreateVesting_scheduleOverflows_failure(t *testing.T) {
+	stub, owner, _ := initERC20WithOwner(t)
+	_, beneficiaryAddress := newCreator(t)
+	res := invokeAs(stub, owner, "txVesting", [][]byte{[]byte("createVesting"), []byte(beneficiaryAddress), []byte("1000"),
+		[]byte(strconv.FormatInt(math.MaxInt64-10, 10)), []byte("0"), []byte("100"), []byte("true")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_TransferWithLock_claim_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	res := invokeAtAs(stub, owner, "txLock", time.Unix(1000, 0), [][]byte{[]byte("transferWithLock"), []byte(ownerAddress), []byte("alice"), []byte("300"), []byte("2000")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// lock holds the tokens, not a balance
+	ownerBalance, _ := repository.GetBalance(stub, ownerAddress, true)
+	escrowBalance, _ := repository.GetBalance(stub, repository.LockEscrow("txLock"), true)
+	if ownerBalance.Cmp(big.NewInt(initAmount-300)) != 0 || escrowBalance.Sign() != 0 {
+		t.FailNow()
+	}
+
+	// locked amount is reported alongside the balance
+	res = invokeAtAs(stub, owner, "txQuery", time.Unix(1500, 0), [][]byte{[]byte("balanceOf"), []byte("alice")})
+	aliceBalance := model.Balance{}
+	if err := json.Unmarshal(res.Payload, &aliceBalance); err != nil || aliceBalance.Balance.Int().Sign() != 0 || aliceBalance.Locked.Int().Cmp(big.NewInt(300)) != 0 {
+		t.FailNow()
+	}
+
+	// lock cannot be claimed before unlockTime
+	res = invokeAtAs(stub, owner, "txClaim1", time.Unix(1500, 0), [][]byte{[]byte("claimLocked"), []byte("alice"), []byte("txLock")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// the other lock of alice doesn't block the claim of txLock
+	res = invokeAtAs(stub, owner, "txLock2", time.Unix(1500, 0), [][]byte{[]byte("transferWithLock"), []byte(ownerAddress), []byte("alice"), []byte("1"), []byte("9000")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = invokeAtAs(stub, owner, "txClaim2", time.Unix(2000, 0), [][]byte{[]byte("claimLocked"), []byte("alice"), []byte("txLock"), []byte("txLock2")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+	res = invokeAtAs(stub, owner, "txClaim3", time.Unix(2000, 0), [][]byte{[]byte("claimLocked"), []byte("alice"), []byte("txLock")})
+	if res.Status != shim.OK || string(res.Payload) != "300" {
+		t.FailNow()
+	}
+
+	balance, _ := repository.GetBalance(stub, "alice", true)
+	locked, _ := repository.GetLockedAmount(stub, "alice")
+	if balance.Cmp(big.NewInt(300)) != 0 || locked.Cmp(big.NewInt(1)) != 0 {
+		t.FailNow()
+	}
+}
+
+func Test_HTLC_claim_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	preimage := []byte("swap secret")
+	hashlock := sha256.Sum256(preimage)
+	res := invokeAtAs(stub, owner, "txHTLC", time.Unix(1000, 0), [][]byte{[]byte("htlcLock"), []byte(ownerAddress), []byte("alice"), []byte("300"),
+		[]byte(hex.EncodeToString(hashlock[:])), []byte("2000")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	nextEvents(t, stub)
+
+	// wrong preimage & refund before expiry fail
+	res = invokeAtAs(stub, owner, "txClaim1", time.Unix(1500, 0), [][]byte{[]byte("htlcClaim"), []byte("txHTLC"), []byte(hex.EncodeToString([]byte("wrong")))})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+	res = invokeAtAs(stub, owner, "txRefund", time.Unix(1500, 0), [][]byte{[]byte("htlcRefund"), []byte("txHTLC")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// claim reveals preimage
+	res = invokeAtAs(stub, owner, "txClaim2", time.Unix(1500, 0), [][]byte{[]byte("htlcClaim"), []byte("txHTLC"), []byte(hex.EncodeToString(preimage))})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	events := nextEvents(t, stub)
+	if len(events) != 2 || events[1].Type != repository.HTLCClaimedEventKey {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, "alice", true)
+	htlc, _ := repository.GetHTLC(stub, "txHTLC")
+	if balance.Cmp(big.NewInt(300)) != 0 || htlc.State != model.HTLCClaimed || htlc.Preimage != hex.EncodeToString(preimage) {
+		t.FailNow()
+	}
+}
+
+func Test_HTLC_refund_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	hashlock := sha256.Sum256([]byte("swap secret"))
+	res := invokeAtAs(stub, owner, "txHTLC", time.Unix(1000, 0), [][]byte{[]byte("htlcLock"), []byte(ownerAddress), []byte("alice"), []byte("300"),
+		[]byte(hex.EncodeToString(hashlock[:])), []byte("2000")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	res = invokeAtAs(stub, owner, "txRefund", time.Unix(2000, 0), [][]byte{[]byte("htlcRefund"), []byte("txHTLC")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, ownerAddress, true)
+	if balance.Cmp(big.NewInt(initAmount)) != 0 {
+		t.FailNow()
+	}
+
+	// refunded HTLC cannot be claimed
+	res = invokeAtAs(stub, owner, "txClaim", time.Unix(1500, 0), [][]byte{[]byte("htlcClaim"), []byte("txHTLC"), []byte(hex.EncodeToString([]byte("swap secret")))})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_HTLC_refundToFrozenSender_failure(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	sender, senderAddress := newCreator(t)
+	res := invokeAs(stub, owner, "txTransfer", [][]byte{[]byte("transfer"), []byte(ownerAddress), []byte(senderAddress), []byte("300")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	hashlock := sha256.Sum256([]byte("swap secret"))
+	res = invokeAtAs(stub, sender, "txHTLC", time.Unix(1000, 0), [][]byte{[]byte("htlcLock"), []byte(senderAddress), []byte("alice"), []byte("300"),
+		[]byte(hex.EncodeToString(hashlock[:])), []byte("2000")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// frozen sender cannot receive the refund
+	res = invokeAs(stub, owner, "txFreeze", [][]byte{[]byte("freeze"), []byte(senderAddress)})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = invokeAtAs(stub, owner, "txRefund", time.Unix(2000, 0), [][]byte{[]byte("htlcRefund"), []byte("txHTLC")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+// otherTokenChaincode is the other token chaincode which returns payload to every invocation
+type otherTokenChaincode struct {
+	payload string
+}
+
+func (cc *otherTokenChaincode) Init(stub shim.ChaincodeStubInterface) sc.Response {
+	return shim.Success(nil)
+}
+
+func (cc *otherTokenChaincode) Invoke(stub shim.ChaincodeStubInterface) sc.Response {
+	return shim.Success([]byte(cc.payload))
+}
+
+func Test_Swap_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	stub.MockPeerChaincode("otherToken", shim.NewMockStub("otherToken", &otherTokenChaincode{payload: "transfer Success"}))
+	stub.MockPeerChaincode("badToken", shim.NewMockStub("badToken", &otherTokenChaincode{payload: "ok"}))
+	caller, callerAddress := newCreator(t)
+	for _, name := range []string{"otherToken", "badToken"} {
+		res := invokeAs(stub, owner, "txTrust", [][]byte{[]byte("addTrustedChaincode"), []byte(name)})
+		if res.Status != shim.OK {
+			t.FailNow()
+		}
+	}
+
+	// counterparty (owner) offers the swap to the caller
+	res := invokeAs(stub, owner, "txOffer", [][]byte{[]byte("offerSwap"), []byte(ownerAddress), []byte(callerAddress), []byte("otherToken"), []byte("500"), []byte("10")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// swap must match the offer
+	res = invokeAs(stub, caller, "txSwap0", [][]byte{[]byte("swap"), []byte("otherToken"), []byte(callerAddress), []byte(ownerAddress), []byte("500"), []byte("1")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	res = invokeAs(stub, caller, "txSwap1", [][]byte{[]byte("swap"), []byte("otherToken"), []byte(callerAddress), []byte(ownerAddress), []byte("500"), []byte("10")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	balance, _ := repository.GetBalance(stub, callerAddress, true)
+	if balance.Cmp(big.NewInt(500)) != 0 {
+		t.FailNow()
+	}
+
+	// offer is consumed by the swap
+	res = invokeAs(stub, caller, "txSwap2", [][]byte{[]byte("swap"), []byte("otherToken"), []byte(callerAddress), []byte(ownerAddress), []byte("500"), []byte("10")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	// other token which returns unexpected payload fails the swap
+	res = invokeAs(stub, owner, "txOffer2", [][]byte{[]byte("offerSwap"), []byte(ownerAddress), []byte(callerAddress), []byte("badToken"), []byte("500"), []byte("10")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	res = invokeAs(stub, caller, "txSwap3", [][]byte{[]byte("swap"), []byte("badToken"), []byte(callerAddress), []byte(ownerAddress), []byte("500"), []byte("10")})
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+}
+
+func Test_TransferOtherToken_trustedChaincode_success(t *testing.T) {
+	stub, owner, ownerAddress := initERC20WithOwner(t)
+	stub.MockPeerChaincode("otherToken", shim.NewMockStub("otherToken", &otherTokenChaincode{payload: "transfer Success"}))
+	arguments := [][]byte{[]byte("transferOtherToken"), []byte("otherToken"), []byte(ownerAddress), []byte("alice"), []byte("10")}
+
+	// unknown chaincode is refused
+	res := invokeAs(stub, owner, "txOther1", arguments)
+	if res.Status != shim.ERROR {
+		t.FailNow()
+	}
+
+	res = invokeAs(stub, owner, "txTrust", [][]byte{[]byte("addTrustedChaincode"), []byte("otherToken")})
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+	events := nextEvents(t, stub)
+	if len(events) != 1 || events[0].Type != repository.TrustedChaincodeAddedEventKey {
+		t.FailNow()
+	}
+	res = invokeAs(stub, owner, "txOther2", arguments)
+	if res.Status != shim.OK {
+		t.FailNow()
+	}
+
+	// audit event
+	events = nextEvents(t, stub)
+	callEvent := model.ExternalCallEvent{}
+	if len(events) != 1 || events[0].Type != repository.ExternalCallEventKey || json.Unmarshal(events[0].Payload, &callEvent) != nil {
+		t.FailNow()
+	}
+	if callEvent.Chaincode != "otherToken" || callEvent.Function != "transfer" {
 		t.FailNow()
 	}
 }
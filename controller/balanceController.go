@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"github.com/erc20/identity"
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// standardBalanceMode is the balance mode of setBalanceMode that saves credits into the balance
+// (delta mode is repository.DeltaBalanceMode)
+const standardBalanceMode = "standard"
+
+// SetBalanceMode is invoke function that changes how the credits of address are saved
+// in delta mode, credits are saved as delta keys so concurrent transfers to address don't conflict
+// the credits are folded into the balance when delta mode is turned off
+// only admin can call this function
+// params - address, mode (standard or delta)
+func (cc *Controller) SetBalanceMode(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	address, mode := params[0], params[1]
+
+	// check address & mode
+	if len(address) == 0 {
+		return shim.Error("address cannot be empty")
+	}
+	if mode != standardBalanceMode && mode != repository.DeltaBalanceMode {
+		return shim.Error("unknown balance mode: " + mode)
+	}
+
+	// caller must be admin
+	_, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save balance mode
+	err = repository.SaveBalanceMode(stub, address, mode == repository.DeltaBalanceMode)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("balance mode is changed"))
+}
+
+// BalanceMode is query function
+// params - address
+// Returns the balance mode of address (standard or delta)
+func (cc *Controller) BalanceMode(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	deltaMode, err := repository.IsDeltaBalanceMode(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if deltaMode {
+		return shim.Success([]byte(repository.DeltaBalanceMode))
+	}
+	return shim.Success([]byte(standardBalanceMode))
+}
+
+// CompactBalance is invoke function that folds the delta credits of address into its balance
+// only address itself or admin can call this function
+// params - address
+// Returns the balance of address
+func (cc *Controller) CompactBalance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	address := params[0]
+	if len(address) == 0 {
+		return shim.Error("address cannot be empty")
+	}
+
+	// caller must be address or admin
+	callerAddress, err := identity.GetAddress(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if callerAddress != address {
+		_, err = checkRole(stub, model.AdminRole)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	// address must be in delta mode
+	deltaMode, err := repository.IsDeltaBalanceMode(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !deltaMode {
+		return shim.Error("address is not in delta mode")
+	}
+
+	balance, err := repository.CompactBalance(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(balance.String()))
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/erc20/identity"
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// maxBatchSize is the maximum number of legs of batch transfer (keeps endorsement fast)
+const maxBatchSize = 100
+
+// BatchTransfer is invoke function that moves tokens from the caller's address
+// to many recipients in one transaction
+// params - caller's address, legs(JSON array of {"recipient": address, "amount": "amount"})
+func (cc *Controller) BatchTransfer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	callerAddress, legsJSON := params[0], params[1]
+
+	// caller must be the transaction creator
+	err := identity.CheckAddress(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert legs
+	legs := []model.TransferLeg{}
+	err = json.Unmarshal([]byte(legsJSON), &legs)
+	if err != nil {
+		return shim.Error(model.NewCustomError(model.UnMarshalErrorType, "legs", err.Error()).Error())
+	}
+	if len(legs) == 0 || len(legs) > maxBatchSize {
+		return shim.Error(fmt.Sprintf("the number of legs must be between 1 and %d", maxBatchSize))
+	}
+
+	// check every leg
+	recipients := make([]string, len(legs))
+	amounts := make([]*big.Int, len(legs))
+	for i, leg := range legs {
+		if len(leg.Recipient) == 0 {
+			return shim.Error(fmt.Sprintf("recipient of leg %d cannot be empty", i))
+		}
+		amount, err := util.ConvertToPositive(fmt.Sprintf("amount of leg %d", i), leg.Amount)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		recipients[i], amounts[i] = leg.Recipient, amount
+	}
+
+	// caller & recipients cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, append([]string{callerAddress}, recipients...)...)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// move tokens & emit transfer events
+	err = repository.BatchTransfer(stub, callerAddress, recipients, amounts)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("batchTransfer success"))
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/json"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// SetCap is invoke function that sets the maximum total supply of token
+// once cap is set, it can only be lowered (but not below the total supply)
+// only admin can call this function
+// params - tokenName, cap
+func (cc *Controller) SetCap(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	tokenName := params[0]
+
+	// check cap is positive
+	capInt, err := util.ConvertToPositive("cap", params[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// caller must be admin
+	_, err = checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check cap is lowered & not less than total supply
+	erc20, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if erc20.GetCap() != nil && capInt.Cmp(erc20.GetCap()) >= 0 {
+		return shim.Error("cap can only be lowered")
+	}
+	if erc20.GetTotalSupply().Cmp(capInt) > 0 {
+		return shim.Error("cap cannot be less than totalSupply")
+	}
+
+	// save cap
+	erc20.Cap = model.NewAmount(capInt)
+	err = repository.SaveERC20Metadata(stub, erc20)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("setCap success"))
+}
+
+// Cap is query function
+// params - tokenName
+// Returns the maximum total supply of token (decimal string, null if there is no cap)
+func (cc *Controller) Cap(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	tokenName := params[0]
+
+	// get ERC20 Metadata
+	erc20, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert cap to bytes
+	capBytes, err := json.Marshal(erc20.Cap)
+	if err != nil {
+		return shim.Error("failed to Marshal cap, error: " + err.Error())
+	}
+
+	return shim.Success(capBytes)
+}
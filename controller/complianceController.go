@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// checkNotRestricted returns error if one of addresses is frozen or blacklisted
+func checkNotRestricted(stub shim.ChaincodeStubInterface, addresses ...string) error {
+	for _, address := range addresses {
+		frozen, err := repository.IsFrozen(stub, address)
+		if err != nil {
+			return err
+		}
+		if frozen {
+			return fmt.Errorf("account %s is frozen", address)
+		}
+
+		blacklisted, err := repository.IsBlacklisted(stub, address)
+		if err != nil {
+			return err
+		}
+		if blacklisted {
+			return fmt.Errorf("account %s is blacklisted", address)
+		}
+	}
+
+	return nil
+}
+
+// Freeze is invoke function that stops address from sending & receiving token
+// only admin can call this function
+// params - address
+func (cc *Controller) Freeze(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.setRestriction(stub, params, repository.SaveFrozen, true, repository.FrozenEventKey)
+}
+
+// Unfreeze is invoke function that lifts the freeze of address
+// only admin can call this function
+// params - address
+func (cc *Controller) Unfreeze(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.setRestriction(stub, params, repository.SaveFrozen, false, repository.UnfrozenEventKey)
+}
+
+// Blacklist is invoke function that adds address to blacklist
+// blacklisted address cannot send & receive token
+// only admin can call this function
+// params - address
+func (cc *Controller) Blacklist(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.setRestriction(stub, params, repository.SaveBlacklisted, true, repository.BlacklistedEventKey)
+}
+
+// Unblacklist is invoke function that removes address from blacklist
+// only admin can call this function
+// params - address
+func (cc *Controller) Unblacklist(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.setRestriction(stub, params, repository.SaveBlacklisted, false, repository.UnblacklistedEventKey)
+}
+
+// setRestriction saves restriction of address with save function & emits compliance event (eventKey)
+func (cc *Controller) setRestriction(stub shim.ChaincodeStubInterface, params []string,
+	save func(shim.ChaincodeStubInterface, string, bool) error, restricted bool, eventKey string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	address := params[0]
+	if len(address) == 0 {
+		return shim.Error("address cannot be empty")
+	}
+
+	// caller must be admin
+	callerAddress, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save restriction
+	err = save(stub, address, restricted)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// emit compliance event
+	err = repository.EmitComplianceEvent(stub, eventKey, address, callerAddress, nil)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(eventKey + " success"))
+}
+
+// WipeFrozen is invoke function that burns every token of frozen address
+// only admin can call this function
+// params - tokenName, address
+func (cc *Controller) WipeFrozen(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	tokenName, address := params[0], params[1]
+
+	// caller must be admin
+	callerAddress, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// address must be frozen
+	frozen, err := repository.IsFrozen(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !frozen {
+		return shim.Error("account " + address + " is not frozen")
+	}
+
+	// burn every token of address
+	balance, err := repository.GetBalance(stub, address, true)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if balance.Sign() > 0 {
+		err = cc.burn(stub, tokenName, address, balance)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	// emit frozen wiped event
+	err = repository.EmitComplianceEvent(stub, repository.FrozenWipedEventKey, address, callerAddress, balance)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("wipeFrozen success"))
+}
+
+// IsFrozen is query function
+// params - address
+// Returns true if address is frozen
+func (cc *Controller) IsFrozen(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.getRestriction(stub, params, repository.IsFrozen)
+}
+
+// IsBlacklisted is query function
+// params - address
+// Returns true if address is blacklisted
+func (cc *Controller) IsBlacklisted(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.getRestriction(stub, params, repository.IsBlacklisted)
+}
+
+// getRestriction returns restriction of address with get function
+func (cc *Controller) getRestriction(stub shim.ChaincodeStubInterface, params []string,
+	get func(shim.ChaincodeStubInterface, string) (bool, error)) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	restricted, err := get(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert restricted to bytes for return
+	response, err := json.Marshal(restricted)
+	if err != nil {
+		return shim.Error("failed to Marshal restriction, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
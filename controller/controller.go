@@ -1,9 +1,13 @@
 package controller
 
 import (
+	"encoding/json"
+	"math/big"
 	"strconv"
 
+	"github.com/erc20/model"
 	"github.com/erc20/repository"
+	"github.com/erc20/util"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
 )
@@ -15,19 +19,47 @@ func NewController() *Controller {
 	return &Controller{}
 }
 
-// Init is called when the chaincode is instantiated by the blockchain network.
-// params - tokenName, symbol, owner(address), amount
+// defaultDecimals is the decimals of token when decimals is not given at Init (same as ether)
+const defaultDecimals = 18
+
+// Init is called when the chaincode is instantiated or upgraded by the blockchain network.
+// owner gets the initial supply and every role
+// the txID of the first Init is saved as the permit domain of this deployment
+// on upgrade the saved state (metadata & cap, balances, roles) is kept and the params are ignored,
+// and on upgrade of a deployment with flat keys only the roles are granted, so admin can call migrateKeys
+// params - tokenName, symbol, owner(address), amount, [decimals], [cap]
 func (cc *Controller) Init(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	if len(params) != 4 {
+	if len(params) < 4 || len(params) > 6 {
 		return shim.Error("incorrect number of parameter")
 	}
 
 	tokenName, symbol, owner, amount := params[0], params[1], params[2], params[3]
 
 	// check amount is unsigned int
-	amountUint, err := strconv.ParseUint(string(amount), 10, 64)
+	amountInt, err := util.ConvertToAmount("amount", amount)
 	if err != nil {
-		return shim.Error("amount must be a number or amount cannot be negative")
+		return shim.Error(err.Error())
+	}
+
+	// check decimals is uint8
+	decimals := uint64(defaultDecimals)
+	if len(params) >= 5 {
+		decimals, err = strconv.ParseUint(params[4], 10, 8)
+		if err != nil {
+			return shim.Error("decimals must be a number between 0 and 255")
+		}
+	}
+
+	// check cap is positive & not less than amount
+	var capInt *big.Int
+	if len(params) == 6 {
+		capInt, err = util.ConvertToPositive("cap", params[5])
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if amountInt.Cmp(capInt) > 0 {
+			return shim.Error("amount cannot exceed cap")
+		}
 	}
 
 	// tokenName & symbol & owner cannot be empty
@@ -35,18 +67,106 @@ func (cc *Controller) Init(stub shim.ChaincodeStubInterface, params []string) sc
 		return shim.Error("tokenName or symbol or owner cannot be emtpy")
 	}
 
-	// save token meta data
-	err = repository.SaveERC20Metadata(stub, tokenName, symbol, owner, amountUint)
+	// save permit domain once, so permits of other deployments are not accepted
+	domain, err := repository.GetPermitDomain(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if len(domain) == 0 {
+		err = repository.SavePermitDomain(stub, stub.GetTxID())
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	// keep the state of upgraded chaincode
+	initialized, err := repository.HasERC20Metadata(stub)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
+	if initialized {
+		return shim.Success(nil)
+	}
 
-	// save owner balance
-	err = repository.SaveBalance(stub, owner, amount)
+	// metadata & balances of flat keys are moved by migrateKeys
+	legacy, err := repository.HasFlatMetadata(stub, tokenName)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
+	if !legacy {
+		// save token meta data
+		erc20 := model.NewERC20MetaData(tokenName, symbol, uint8(decimals), owner, amountInt)
+		erc20.Cap = model.NewAmount(capInt)
+		err = repository.SaveERC20Metadata(stub, erc20)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+
+		// save owner balance
+		err = repository.SaveBalance(stub, owner, amountInt)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	// grant every role to owner & emit role granted events
+	// (owner is the sender, since there is no admin before Init)
+	for _, role := range model.Roles {
+		err = repository.SaveRole(stub, role, owner)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		err = repository.EmitRoleEvent(stub, repository.RoleGrantedEventKey, role, owner, owner)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
 
 	// response
 	return shim.Success(nil)
 }
+
+// maxMigrationPageSize is the maximum number of flat keys examined by one migrateKeys call
+const maxMigrationPageSize = 500
+
+// MigrateKeys is invoke function that moves token metadata & balances saved under flat keys
+// into the composite key layout, one page of flat keys per call
+// only admin can call this function
+// params - pageSize, [bookmark] (returned by the previous call)
+// Returns the list of migrated & skipped keys and the bookmark of next page
+func (cc *Controller) MigrateKeys(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1 or 2
+	if len(params) != 1 && len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	pageSize, err := strconv.Atoi(params[0])
+	if err != nil || pageSize <= 0 || pageSize > maxMigrationPageSize {
+		return shim.Error("pageSize must be between 1 and " + strconv.Itoa(maxMigrationPageSize))
+	}
+	bookmark := ""
+	if len(params) == 2 {
+		bookmark = params[1]
+	}
+
+	// caller must be admin
+	_, err = checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// migrate keys
+	result, err := repository.MigrateFlatKeys(stub, pageSize, bookmark)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert result to bytes for return
+	response, err := json.Marshal(result)
+	if err != nil {
+		return shim.Error("failed to Marshal migration result, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/erc20/identity"
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// HTLCLock is invoke function that moves amount token from the caller's address into new HTLC,
+// which recipient can claim with the preimage of hashlock before timelock
+// params - caller's address, recipient's address, amount of token, hashlock (hex of SHA-256), timelock (unix time)
+// Returns the id of new HTLC
+func (cc *Controller) HTLCLock(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 5
+	if len(params) != 5 {
+		return shim.Error("incorrect number of params")
+	}
+
+	callerAddress, recipientAddress, transferAmount, hashlock := params[0], params[1], params[2], params[3]
+	if len(recipientAddress) == 0 {
+		return shim.Error("invalid recipient: " + recipientAddress)
+	}
+
+	// caller must be the transaction creator
+	err := identity.CheckAddress(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check amount is integer & positive
+	transferAmountInt, err := util.ConvertToPositive("transferAmount", transferAmount)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check hashlock is SHA-256 hash
+	hashlockBytes, err := hex.DecodeString(hashlock)
+	if err != nil || len(hashlockBytes) != sha256.Size {
+		return shim.Error("hashlock must be hex of SHA-256 hash")
+	}
+
+	// check timelock is in the future
+	timelock, err := strconv.ParseInt(params[4], 10, 64)
+	if err != nil {
+		return shim.Error("timelock must be unix time")
+	}
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if timelock <= now {
+		return shim.Error("timelock must be in the future")
+	}
+
+	// caller & recipient cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, callerAddress, recipientAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// move token into HTLC & emit transfer event
+	id := stub.GetTxID()
+	err = repository.Escrow(stub, callerAddress, repository.HTLCEscrow(id), transferAmountInt, "htlc "+id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save HTLC & emit locked event
+	htlc := model.NewHTLC(id, callerAddress, recipientAddress, transferAmountInt, hex.EncodeToString(hashlockBytes), timelock)
+	err = saveHTLC(stub, htlc, repository.HTLCLockedEventKey)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(id))
+}
+
+// HTLCClaim is invoke function that pays HTLC to recipient when SHA-256 of preimage matches the hashlock
+// anyone can call this function since tokens are paid only to recipient
+// params - HTLC id, preimage (hex)
+func (cc *Controller) HTLCClaim(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	id := params[0]
+	preimage, err := hex.DecodeString(params[1])
+	if err != nil {
+		return shim.Error("preimage must be hex")
+	}
+
+	// get locked HTLC
+	htlc, err := getLockedHTLC(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check HTLC is not expired
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if now >= htlc.Timelock {
+		return shim.Error("htlc " + id + " is expired")
+	}
+
+	// check preimage
+	hash := sha256.Sum256(preimage)
+	if hex.EncodeToString(hash[:]) != htlc.Hashlock {
+		return shim.Error("preimage doesn't match hashlock")
+	}
+
+	// recipient cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, htlc.Recipient)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// pay recipient from HTLC & emit transfer event
+	err = repository.ReleaseEscrow(stub, repository.HTLCEscrow(id), htlc.Recipient, htlc.Amount.Int(), "htlc "+id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save preimage & emit claimed event
+	htlc.State = model.HTLCClaimed
+	htlc.Preimage = hex.EncodeToString(preimage)
+	err = saveHTLC(stub, htlc, repository.HTLCClaimedEventKey)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("htlcClaim success"))
+}
+
+// HTLCRefund is invoke function that returns expired HTLC to sender
+// anyone can call this function since tokens are paid only to sender
+// params - HTLC id
+func (cc *Controller) HTLCRefund(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	id := params[0]
+
+	// get locked HTLC
+	htlc, err := getLockedHTLC(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check HTLC is expired
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if now < htlc.Timelock {
+		return shim.Error("htlc " + id + " is not expired")
+	}
+
+	// sender cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, htlc.Sender)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// pay sender from HTLC & emit transfer event
+	err = repository.ReleaseEscrow(stub, repository.HTLCEscrow(id), htlc.Sender, htlc.Amount.Int(), "htlc refund "+id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save state & emit refunded event
+	htlc.State = model.HTLCRefunded
+	err = saveHTLC(stub, htlc, repository.HTLCRefundedEventKey)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("htlcRefund success"))
+}
+
+// HTLC is query function
+// params - HTLC id
+// Returns HTLC (with preimage when claimed)
+func (cc *Controller) HTLC(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	htlc, err := repository.GetHTLC(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert htlc to bytes for return
+	response, err := json.Marshal(htlc)
+	if err != nil {
+		return shim.Error("failed to Marshal htlc, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// getLockedHTLC returns HTLC of id or error if it is already claimed or refunded
+func getLockedHTLC(stub shim.ChaincodeStubInterface, id string) (*model.HTLC, error) {
+	htlc, err := repository.GetHTLC(stub, id)
+	if err != nil {
+		return nil, err
+	}
+	if htlc.State != model.HTLCLocked {
+		return nil, errors.New("htlc " + id + " is already " + htlc.State)
+	}
+
+	return htlc, nil
+}
+
+// saveHTLC saves htlc & emits its lifecycle event
+func saveHTLC(stub shim.ChaincodeStubInterface, htlc *model.HTLC, eventKey string) error {
+	err := repository.SaveHTLC(stub, htlc)
+	if err != nil {
+		return err
+	}
+
+	return repository.EmitHTLCEvent(stub, eventKey, htlc)
+}
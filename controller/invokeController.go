@@ -1,96 +1,132 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 
+	"github.com/erc20/identity"
+	"github.com/erc20/model"
 	"github.com/erc20/repository"
 	"github.com/erc20/util"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
 )
 
+// burnAddress is the recipient of transfer event when tokens are burned
+const burnAddress = "0000000000000000000000000000000000000000"
+
+// maxMemoLength is the maximum length of transfer memo in bytes
+const maxMemoLength = 256
+
+// transferSuccessPayload is the payload of successful transfer
+// (other token chaincodes are checked to return the same payload)
+const transferSuccessPayload = "transfer Success"
+
 // Transfer is invoke function that moves amount token
 // from the caller's address to recipient
-// params - caller's address, recipient's address, amount of token
+// params - caller's address, recipient's address, amount of token, [memo]
 func (cc *Controller) Transfer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	// check the number of params is 3
-	if len(params) != 3 {
+	// check the number of params is 3 or 4
+	if len(params) != 3 && len(params) != 4 {
 		return shim.Error("incorrect number of parameters")
 	}
 
 	callerAddress, recipientAddress, transferAmount := params[0], params[1], params[2]
 
-	// check amount is integer & positive
-	transferAmountInt, err := util.ConvertToPositive("transferAmount", transferAmount)
-	if err != nil {
-		return shim.Error(err.Error())
+	// check memo length
+	memo := ""
+	if len(params) == 4 {
+		memo = params[3]
+	}
+	if len(memo) > maxMemoLength {
+		return shim.Error("memo cannot be longer than " + strconv.Itoa(maxMemoLength) + " bytes")
 	}
 
-	// get caller amount
-	callerAmountInt, err := repository.GetBalance(stub, callerAddress, false)
+	// caller must be the transaction creator
+	err := identity.CheckAddress(stub, callerAddress)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// get recipient amount
-	recipientAmountInt, err := repository.GetBalance(stub, recipientAddress, true)
+	// check amount is integer & positive
+	transferAmountInt, err := util.ConvertToPositive("transferAmount", transferAmount)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// calculate amount
-	callerResultAmount := *callerAmountInt - *transferAmountInt
-	recipientResultAmount := *recipientAmountInt + *transferAmountInt
-
-	// check callerReuslt Amount is positive
-	if callerResultAmount < 0 {
-		return shim.Error("caller's balance is not sufficient")
-	}
-
-	// save the caller's & recipient's amount
-	err = repository.SaveBalance(stub, callerAddress, strconv.Itoa(callerResultAmount))
+	// caller & recipient cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, callerAddress, recipientAddress)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	err = repository.SaveBalance(stub, recipientAddress, strconv.Itoa(recipientResultAmount))
+
+	// move token, record activity & emit transfer event
+	err = repository.Transfer(stub, callerAddress, recipientAddress, transferAmountInt, memo)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// emit transfer event
-	err = repository.EmitTransferEvent(stub, callerAddress, recipientAddress, *transferAmountInt)
-
-	return shim.Success([]byte("transfer Success"))
+	return shim.Success([]byte(transferSuccessPayload))
 }
 
 // Approve is invoke function that Sets amount as the allowance
 // of spender over the owner tokens
-// params - owner's address, spender's address, amount of token
+// the allowance lapses at expiresAt (unix time in seconds), it never expires if expiresAt is not given
+// params - owner's address, spender's address, amount of token, [expiresAt]
 func (cc *Controller) Approve(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
-	// check the number of params is 3
-	if len(params) != 3 {
+	// check the number of params is 3 or 4
+	if len(params) != 3 && len(params) != 4 {
 		return shim.Error("incorrect number of parameters")
 	}
 
 	ownerAddress, spenderAddress, allowanceAmount := params[0], params[1], params[2]
 
-	// check amount is integer & positive
-	allowanceAmountInt, err := util.ConvertToPositive("AllowanceAmount", allowanceAmount)
+	// owner must be the transaction creator
+	err := identity.CheckAddress(stub, ownerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check expiresAt is after the transaction time
+	var expiresAt int64
+	if len(params) == 4 {
+		expiresAt, err = strconv.ParseInt(params[3], 10, 64)
+		if err != nil {
+			return shim.Error("expiresAt must be unix time")
+		}
+		now, err := util.GetTxTime(stub)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if expiresAt <= now {
+			return shim.Error("expiresAt must be after the transaction time")
+		}
+	}
+
+	return cc.approve(stub, ownerAddress, spenderAddress, allowanceAmount, expiresAt)
+}
+
+// approve sets amount as the allowance of spender over the owner tokens without checking the caller
+func (cc *Controller) approve(stub shim.ChaincodeStubInterface, ownerAddress, spenderAddress, allowanceAmount string, expiresAt int64) sc.Response {
+
+	// check amount is integer & not negative (zero means no allowance)
+	allowanceAmountInt, err := util.ConvertToAmount("AllowanceAmount", allowanceAmount)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
 	// save allowance amount
-	err = repository.SaveAllowance(stub, ownerAddress, spenderAddress, allowanceAmount)
+	err = repository.SaveAllowance(stub, ownerAddress, spenderAddress, allowanceAmountInt, expiresAt)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
 	// emit approval event
-	err = repository.EmitApprovalEvent(stub, ownerAddress, spenderAddress, *allowanceAmountInt)
+	err = repository.EmitApprovalEvent(stub, ownerAddress, spenderAddress, allowanceAmountInt)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
@@ -98,6 +134,76 @@ func (cc *Controller) Approve(stub shim.ChaincodeStubInterface, params []string)
 	return shim.Success([]byte("approve success"))
 }
 
+// Revoke is invoke function that removes the allowance of spender over the owner tokens
+// params - owner's address, spender's address
+func (cc *Controller) Revoke(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	ownerAddress, spenderAddress := params[0], params[1]
+
+	// owner must be the transaction creator
+	err := identity.CheckAddress(stub, ownerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// delete allowance & emit approval event
+	err = cc.revoke(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("revoke success"))
+}
+
+// RevokeAll is invoke function that removes every allowance over the owner tokens
+// params - owner's address
+func (cc *Controller) RevokeAll(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	ownerAddress := params[0]
+
+	// owner must be the transaction creator
+	err := identity.CheckAddress(stub, ownerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get approval list
+	approvalSlice, err := repository.GetApprovalList(stub, ownerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// delete every allowance & emit approval events
+	for _, approval := range approvalSlice {
+		err = cc.revoke(stub, ownerAddress, approval.Spender)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+	}
+
+	return shim.Success([]byte("revokeAll success"))
+}
+
+// revoke deletes the allowance of spender & emits approval event with zero allowance
+func (cc *Controller) revoke(stub shim.ChaincodeStubInterface, ownerAddress, spenderAddress string) error {
+	err := repository.DeleteAllowance(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return err
+	}
+
+	return repository.EmitApprovalEvent(stub, ownerAddress, spenderAddress, big.NewInt(0))
+}
+
 // TransferFrom is invoke function that Moves amount of tokens from sender(owner) to recipient
 // using allowance of spender
 // parmas - owner's address, spender's address, recipient's address, amount of token
@@ -110,38 +216,28 @@ func (cc *Controller) TransferFrom(stub shim.ChaincodeStubInterface, params []st
 
 	ownerAddress, spenderAddress, recipientAddress, transferAmount := params[0], params[1], params[2], params[3]
 
-	// check amount is integer & positive
-	transferAmountInt, err := util.ConvertToPositive("TransferAmount", transferAmount)
+	// spender must be the transaction creator
+	err := identity.CheckAddress(stub, spenderAddress)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// get allowance
-	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
-	if allowanceResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get allowance, error: " + allowanceResponse.GetMessage())
-	}
-
-	// convert allowance response paylaod to allowance data
-	allowanceInt, err := strconv.Atoi(string(allowanceResponse.GetPayload()))
+	// check amount is integer & positive
+	transferAmountInt, err := util.ConvertToPositive("TransferAmount", transferAmount)
 	if err != nil {
-		return shim.Error("allowance must be positive")
+		return shim.Error(err.Error())
 	}
 
-	// transfer from owner to recipient
-	transferResponse := cc.Transfer(stub, []string{ownerAddress, recipientAddress, transferAmount})
-	if transferResponse.GetStatus() >= 400 {
-		return shim.Error("failed to transfer, error: " + transferResponse.GetMessage())
+	// owner, spender & recipient cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, ownerAddress, spenderAddress, recipientAddress)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
 
-	// decrease allowance amount
-	approveAmountInt := allowanceInt - *transferAmountInt
-	approveAmount := strconv.Itoa(approveAmountInt)
-
-	// approve amount of tokens transfered
-	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, approveAmount})
-	if approveResponse.GetStatus() >= 400 {
-		return shim.Error("failed to approve, error: " + approveResponse.GetMessage())
+	// check & decrease allowance, move token and emit transfer & approval events
+	err = repository.TransferFrom(stub, ownerAddress, spenderAddress, recipientAddress, transferAmountInt)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
 
 	return shim.Success([]byte("transferFrom success"))
@@ -149,6 +245,9 @@ func (cc *Controller) TransferFrom(stub shim.ChaincodeStubInterface, params []st
 
 // TransferOtherToken is invoke function that Moves amount other chaincode tokens
 // from the caller's address to recipient
+// chaincode must be added to trusted chaincodes by admin
+// only successful call is audited by externalCallEvent,
+// failed or refused call fails the transaction, so it leaves no event
 // params - chaincode name, caller's address, recipient's address, amount
 func (cc *Controller) TransferOtherToken(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
@@ -159,21 +258,55 @@ func (cc *Controller) TransferOtherToken(stub shim.ChaincodeStubInterface, param
 
 	chaincodeName, callerAddress, recipientAddress, transferAmount := params[0], params[1], params[2], params[3]
 
-	// make arguments
-	args := [][]byte{[]byte("transfer"), []byte(callerAddress), []byte(recipientAddress), []byte(transferAmount)}
+	// caller must be the transaction creator
+	err := identity.CheckAddress(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 
-	// get channel
-	channel := stub.GetChannelID()
+	// caller & recipient cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, callerAddress, recipientAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
 
 	// transfer other chaincode token
-	transferResponse := stub.InvokeChaincode(chaincodeName, args, channel)
-	if transferResponse.GetStatus() >= 400 {
-		return shim.Error(fmt.Sprintf("failed to transfer %s, error: %s", chaincodeName, transferResponse.GetMessage()))
+	err = transferOtherToken(stub, chaincodeName, callerAddress, recipientAddress, transferAmount)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
 
 	return shim.Success([]byte("transfer other token success"))
 }
 
+// transferOtherToken invokes transfer of trusted chaincodeName on the same channel & emits audit event
+// Returns error unless the response is OK with the payload of successful transfer
+func transferOtherToken(stub shim.ChaincodeStubInterface, chaincodeName, callerAddress, recipientAddress, transferAmount string) error {
+	// chaincode must be trusted by admin
+	trusted, err := repository.IsTrustedChaincode(stub, chaincodeName)
+	if err != nil {
+		return err
+	}
+	if !trusted {
+		return fmt.Errorf("chaincode %s is not trusted", chaincodeName)
+	}
+
+	// make arguments
+	args := [][]byte{[]byte("transfer"), []byte(callerAddress), []byte(recipientAddress), []byte(transferAmount)}
+
+	// transfer other chaincode token
+	transferResponse := stub.InvokeChaincode(chaincodeName, args, stub.GetChannelID())
+	if transferResponse.GetStatus() != shim.OK {
+		return fmt.Errorf("failed to transfer %s, error: %s", chaincodeName, transferResponse.GetMessage())
+	}
+	if string(transferResponse.GetPayload()) != transferSuccessPayload {
+		return fmt.Errorf("failed to transfer %s, unexpected payload: %s", chaincodeName, string(transferResponse.GetPayload()))
+	}
+
+	// emit audit event
+	return repository.EmitExternalCallEvent(stub, callerAddress, chaincodeName, "transfer")
+}
+
 // IncreaseAllowance is invoke function that increases spender's allowance by owner
 // params - owner's address, spender's address, amount of amount
 func (cc *Controller) IncreaseAllowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
@@ -185,6 +318,12 @@ func (cc *Controller) IncreaseAllowance(stub shim.ChaincodeStubInterface, params
 
 	ownerAddress, spenderAddress, increaseAmount := params[0], params[1], params[2]
 
+	// owner must be the transaction creator
+	err := identity.CheckAddress(stub, ownerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
 	// check amount is integer & positive
 	increaseAmountInt, err := util.ConvertToPositive("IncreaseAmount", increaseAmount)
 	if err != nil {
@@ -192,23 +331,25 @@ func (cc *Controller) IncreaseAllowance(stub shim.ChaincodeStubInterface, params
 	}
 
 	// get allowance
-	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
-	if allowanceResponse.GetStatus() >= 400 {
-		return shim.Error("failed to get allowance, error: " + allowanceResponse.GetMessage())
+	allowance, err := repository.GetAllowanceRecord(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
 
-	// convert allowance response paylaod to allowance data
-	allowanceInt, err := strconv.Atoi(string(allowanceResponse.GetPayload()))
-	if err != nil {
-		return shim.Error("allowance must be positive")
+	// expired allowance cannot be increased
+	if allowance.Expired {
+		return shim.Error("allowance is expired, approve again")
 	}
 
 	// increase allowance
-	resultAmountInt := allowanceInt + *increaseAmountInt
-	resultAmount := strconv.Itoa(resultAmountInt)
+	resultAmountInt, err := util.Add(allowance.Allowance.Int(), increaseAmountInt)
+	if err != nil {
+		return shim.Error("allowance " + err.Error())
+	}
+	resultAmount := resultAmountInt.String()
 
-	// call approve
-	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, resultAmount})
+	// call approve (expiry is not changed)
+	approveResponse := cc.approve(stub, ownerAddress, spenderAddress, resultAmount, allowance.ExpiresAt)
 	if approveResponse.GetStatus() >= 400 {
 		return shim.Error("failed to approve allowance, error: " + approveResponse.GetMessage())
 	}
@@ -227,33 +368,33 @@ func (cc *Controller) DecreaseAllowance(stub shim.ChaincodeStubInterface, params
 
 	ownerAddress, spenderAddress, decreaseAmount := params[0], params[1], params[2]
 
-	// check amount is integer & positive
-	decreaseAmountInt, err := util.ConvertToPositive("DecreaseAmount", decreaseAmount)
+	// owner must be the transaction creator
+	err := identity.CheckAddress(stub, ownerAddress)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	// get allowance
-	allowanceResponse := cc.Allowance(stub, []string{ownerAddress, spenderAddress})
-	if allowanceResponse.Status >= 400 {
-		return shim.Error("failed to get allowance, error: " + allowanceResponse.GetMessage())
+	// check amount is integer & positive
+	decreaseAmountInt, err := util.ConvertToPositive("DecreaseAmount", decreaseAmount)
+	if err != nil {
+		return shim.Error(err.Error())
 	}
 
-	// convert allowance response payload to allowance data
-	allowanceInt, err := strconv.Atoi(string(allowanceResponse.GetPayload()))
+	// get allowance (expired allowance is zero)
+	allowance, err := repository.GetAllowanceRecord(stub, ownerAddress, spenderAddress)
 	if err != nil {
-		return shim.Error("allowance must be positive")
+		return shim.Error(err.Error())
 	}
 
 	// calculate allowance (allowance cannot be negative!!)
-	resultAmountInt := allowanceInt - *decreaseAmountInt
-	if resultAmountInt < 0 {
-		resultAmountInt = 0
+	resultAmountInt, err := util.Sub(allowance.Allowance.Int(), decreaseAmountInt)
+	if err != nil {
+		resultAmountInt = big.NewInt(0)
 	}
-	resultAmount := strconv.Itoa(resultAmountInt)
+	resultAmount := resultAmountInt.String()
 
-	// call approve
-	approveResponse := cc.Approve(stub, []string{ownerAddress, spenderAddress, resultAmount})
+	// call approve (expiry is not changed)
+	approveResponse := cc.approve(stub, ownerAddress, spenderAddress, resultAmount, allowance.ExpiresAt)
 	if approveResponse.GetStatus() >= 400 {
 		return shim.Error("failed to approve allowance, error: " + approveResponse.GetMessage())
 	}
@@ -262,6 +403,7 @@ func (cc *Controller) DecreaseAllowance(stub shim.ChaincodeStubInterface, params
 }
 
 // Mint is invoke function That Creates amount tokens and assign them to address, increasing the total supply
+// only minter can call this function
 // params - tokenName, recipient's addresss, amount
 func (cc *Controller) Mint(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
@@ -272,6 +414,18 @@ func (cc *Controller) Mint(stub shim.ChaincodeStubInterface, params []string) sc
 
 	tokenName, address, mintAmount := params[0], params[1], params[2]
 
+	// caller must be minter
+	_, err := checkRole(stub, model.MinterRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// recipient cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
 	// amount must be positive
 	mintAmountInt, err := util.ConvertToPositive("mintAmount", mintAmount)
 	if err != nil {
@@ -283,25 +437,31 @@ func (cc *Controller) Mint(stub shim.ChaincodeStubInterface, params []string) sc
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	resultTotalSupply := *erc20Metadata.GetTotalSupply() + uint64(*mintAmountInt)
-	err = repository.SaveERC20Metadata(stub, *erc20Metadata.GetName(), *erc20Metadata.GetSymbol(), *erc20Metadata.GetOwner(), resultTotalSupply)
+	resultTotalSupply, err := util.Add(erc20Metadata.GetTotalSupply(), mintAmountInt)
+	if err != nil {
+		return shim.Error("totalSupply " + err.Error())
+	}
+	if erc20Metadata.IsCapExceeded(resultTotalSupply) {
+		return shim.Error("totalSupply cannot exceed cap " + erc20Metadata.GetCap().String())
+	}
+	erc20Metadata.TotalSupply = model.NewAmount(resultTotalSupply)
+	err = repository.SaveERC20Metadata(stub, erc20Metadata)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
 	// increase owner balance
-	curBalance, err := repository.GetBalance(stub, address, true)
+	err = repository.CreditBalance(stub, address, mintAmountInt)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-	resultBalance := *curBalance + *mintAmountInt
-	err = repository.SaveBalance(stub, address, strconv.Itoa(resultBalance))
+
+	// record activity & emit transfer event
+	err = repository.RecordActivity(stub, address, "admin", model.ActivityIn, mintAmountInt, "", 0)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
-
-	// emit transfer event
-	err = repository.EmitTransferEvent(stub, "admin", address, *mintAmountInt)
+	err = repository.EmitTransferEvent(stub, "admin", address, mintAmountInt)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
@@ -309,6 +469,141 @@ func (cc *Controller) Mint(stub shim.ChaincodeStubInterface, params []string) sc
 	return shim.Success([]byte("mint success"))
 }
 
+// Burn is invoke function that Destroys amount tokens from the caller, reducing the total supply
+// only burner can call this function
+// params - tokenName, caller's address, amount
 func (cc *Controller) Burn(stub shim.ChaincodeStubInterface, params []string) sc.Response {
-	return shim.Success(nil)
+
+	// check the number of params is 3
+	if len(params) != 3 {
+		return shim.Error("incorrect number of params")
+	}
+
+	tokenName, callerAddress, burnAmount := params[0], params[1], params[2]
+
+	// caller must be the transaction creator & burner
+	err := identity.CheckAddress(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	_, err = checkRole(stub, model.BurnerRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// amount must be positive
+	burnAmountInt, err := util.ConvertToPositive("burnAmount", burnAmount)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// burn tokens of caller
+	err = cc.burn(stub, tokenName, callerAddress, burnAmountInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("burn success"))
+}
+
+// BurnFrom is invoke function that Destroys amount tokens from owner using allowance of spender,
+// reducing the total supply
+// only burner can call this function
+// params - tokenName, owner's address, spender's address, amount
+func (cc *Controller) BurnFrom(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return shim.Error("incorrect number of params")
+	}
+
+	tokenName, ownerAddress, spenderAddress, burnAmount := params[0], params[1], params[2], params[3]
+
+	// spender must be the transaction creator & burner
+	err := identity.CheckAddress(stub, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	_, err = checkRole(stub, model.BurnerRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// amount must be positive
+	burnAmountInt, err := util.ConvertToPositive("burnAmount", burnAmount)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get allowance (expired allowance is zero)
+	allowance, err := repository.GetAllowanceRecord(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check allowance is sufficient
+	resultAllowance, err := util.Sub(allowance.Allowance.Int(), burnAmountInt)
+	if err != nil {
+		return shim.Error("spender's allowance is not sufficient")
+	}
+
+	// decrease allowance (expiry is not changed)
+	err = repository.SaveAllowance(stub, ownerAddress, spenderAddress, resultAllowance, allowance.ExpiresAt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = repository.EmitApprovalEvent(stub, ownerAddress, spenderAddress, resultAllowance)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// burn tokens of owner
+	err = cc.burn(stub, tokenName, ownerAddress, burnAmountInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("burnFrom success"))
+}
+
+// burn destroys amount tokens of address, decreasing the total supply
+func (cc *Controller) burn(stub shim.ChaincodeStubInterface, tokenName, address string, burnAmount *big.Int) error {
+
+	// decrease balance
+	curBalance, err := repository.GetBalance(stub, address, true)
+	if err != nil {
+		return err
+	}
+	resultBalance, err := util.Sub(curBalance, burnAmount)
+	if err != nil {
+		return errors.New("balance is not sufficient")
+	}
+
+	// decrease TotalSupply
+	erc20Metadata, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return err
+	}
+	resultTotalSupply, err := util.Sub(erc20Metadata.GetTotalSupply(), burnAmount)
+	if err != nil {
+		return errors.New("totalSupply cannot be negative")
+	}
+	erc20Metadata.TotalSupply = model.NewAmount(resultTotalSupply)
+
+	// save balance & TotalSupply
+	err = repository.SaveBalance(stub, address, resultBalance)
+	if err != nil {
+		return err
+	}
+	err = repository.SaveERC20Metadata(stub, erc20Metadata)
+	if err != nil {
+		return err
+	}
+
+	// record activity & emit transfer event to burn address
+	err = repository.RecordActivity(stub, address, burnAddress, model.ActivityOut, burnAmount, "", 0)
+	if err != nil {
+		return err
+	}
+	return repository.EmitTransferEvent(stub, address, burnAddress, burnAmount)
 }
@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/erc20/identity"
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/cid"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// maxStatePageSize is the maximum number of keys in one page of state query
+const maxStatePageSize = 100
+
+// TransactionAPI is query function
+// only admin can call this function
+// params - none
+// Returns the txID, channel, timestamp, creator & arguments of the transaction
+func (cc *Controller) TransactionAPI(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 0
+	if len(params) != 0 {
+		return shim.Error("incorrect number of params")
+	}
+
+	// caller must be admin
+	_, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get transaction context
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return shim.Error(model.NewCustomError(model.GetTxTimestampErrorType, "txTimestamp", err.Error()).Error())
+	}
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		return shim.Error(model.NewCustomError(model.GetCreatorErrorType, "mspID", err.Error()).Error())
+	}
+	address, err := identity.GetAddress(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	fcn, args := stub.GetFunctionAndParameters()
+
+	txInfo := model.TransactionInfo{
+		TxID:      stub.GetTxID(),
+		ChannelID: stub.GetChannelID(),
+		Timestamp: time.Unix(txTimestamp.GetSeconds(), int64(txTimestamp.GetNanos())).UTC().Format(time.RFC3339Nano),
+		MSPID:     mspID,
+		Address:   address,
+		Function:  fcn,
+		Params:    args,
+	}
+
+	// convert txInfo to bytes for return
+	response, err := json.Marshal(txInfo)
+	if err != nil {
+		return shim.Error("failed to Marshal txInfo, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// StateDataAPI is query function
+// only admin can call this function
+// params - pageSize, bookmark, startKey, endKey
+// Returns one page of world state between startKey & endKey & the bookmark of next page
+// (composite keys are not included, use StateDataAPI2)
+func (cc *Controller) StateDataAPI(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return shim.Error("incorrect number of params")
+	}
+
+	pageSize, err := checkLedgerQuery(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	bookmark, startKey, endKey := params[1], params[2], params[3]
+
+	// get state page
+	statePage, err := repository.GetStatePage(stub, startKey, endKey, pageSize, bookmark)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return stateResponse(statePage)
+}
+
+// StateDataAPI2 is query function
+// only admin can call this function
+// params - pageSize, bookmark, objectType, [attributes...]
+// Returns one page of world state under the composite key objectType/{attributes...} & the bookmark of next page
+func (cc *Controller) StateDataAPI2(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 3 or more
+	if len(params) < 3 {
+		return shim.Error("incorrect number of params")
+	}
+
+	pageSize, err := checkLedgerQuery(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	bookmark, objectType, attributes := params[1], params[2], params[3:]
+	if len(objectType) == 0 {
+		return shim.Error("objectType cannot be empty")
+	}
+
+	// get state page
+	statePage, err := repository.GetCompositeStatePage(stub, objectType, attributes, pageSize, bookmark)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return stateResponse(statePage)
+}
+
+// HistoryAPI is query function
+// only admin can call this function
+// params - key
+// Returns every modification of key with its txID, timestamp & isDelete
+func (cc *Controller) HistoryAPI(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	key := params[0]
+	if len(key) == 0 {
+		return shim.Error("key cannot be empty")
+	}
+
+	// caller must be admin
+	_, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get key history
+	history, err := repository.GetKeyHistory(stub, key)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert history to bytes for return
+	response, err := json.Marshal(history)
+	if err != nil {
+		return shim.Error("failed to Marshal history, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// checkLedgerQuery checks the caller is admin & returns the page size
+func checkLedgerQuery(stub shim.ChaincodeStubInterface, pageSizeParam string) (int32, error) {
+	pageSize, err := strconv.ParseInt(pageSizeParam, 10, 32)
+	if err != nil || pageSize <= 0 || pageSize > maxStatePageSize {
+		return 0, model.NewCustomError(model.ConvertErrorType, "pageSize", "pageSize must be between 1 and "+strconv.Itoa(maxStatePageSize))
+	}
+
+	// caller must be admin
+	_, err = checkRole(stub, model.AdminRole)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(pageSize), nil
+}
+
+// stateResponse converts statePage to response
+func stateResponse(statePage *model.StatePage) sc.Response {
+	response, err := json.Marshal(statePage)
+	if err != nil {
+		return shim.Error("failed to Marshal statePage, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
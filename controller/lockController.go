@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/erc20/identity"
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// TransferWithLock is invoke function that moves amount token from the caller's address
+// into new lock, which recipient can claim from unlockTime
+// params - caller's address, recipient's address, amount of token, unlockTime (unix time)
+// Returns the id of new lock
+func (cc *Controller) TransferWithLock(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 4
+	if len(params) != 4 {
+		return shim.Error("incorrect number of params")
+	}
+
+	callerAddress, recipientAddress, transferAmount := params[0], params[1], params[2]
+	if len(recipientAddress) == 0 {
+		return shim.Error("invalid recipient: " + recipientAddress)
+	}
+
+	// caller must be the transaction creator
+	err := identity.CheckAddress(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check amount is integer & positive
+	transferAmountInt, err := util.ConvertToPositive("transferAmount", transferAmount)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check unlockTime is in the future
+	unlockTime, err := strconv.ParseInt(params[3], 10, 64)
+	if err != nil {
+		return shim.Error("unlockTime must be unix time")
+	}
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if unlockTime <= now {
+		return shim.Error("unlockTime must be in the future")
+	}
+
+	// caller & recipient cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, callerAddress, recipientAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// move token into lock & emit transfer event
+	id := stub.GetTxID()
+	err = repository.Escrow(stub, callerAddress, repository.LockEscrow(id), transferAmountInt, "lock "+id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save lock
+	lock := model.NewTokenLock(id, callerAddress, recipientAddress, transferAmountInt, unlockTime)
+	err = repository.SaveLock(stub, lock)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(id))
+}
+
+// maxClaimLocks is the maximum number of locks claimed by one claimLocked call
+const maxClaimLocks = 50
+
+// maxLockPageSize is the maximum number of locks in one page of lockedBalanceOf
+const maxLockPageSize = 100
+
+// ClaimLocked is invoke function that pays the matured locks of lockIds to recipient
+// locks are claimed by id, so locks sent by others cannot make the claim of recipient too large
+// anyone can call this function since tokens are paid only to recipient
+// params - recipient's address, lockId... (at most maxClaimLocks)
+// Returns the claimed amount
+func (cc *Controller) ClaimLocked(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is between 2 and maxClaimLocks + 1
+	if len(params) < 2 || len(params) > maxClaimLocks+1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	recipientAddress, lockIDs := params[0], params[1:]
+
+	// recipient cannot be frozen or blacklisted
+	err := checkNotRestricted(stub, recipientAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get locks (every lock must be matured)
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	locks := []*model.TokenLock{}
+	claimedIDs := map[string]bool{}
+	for _, id := range lockIDs {
+		if claimedIDs[id] {
+			return shim.Error("lock " + id + " is given more than once")
+		}
+		claimedIDs[id] = true
+
+		lock, err := repository.GetLock(stub, recipientAddress, id)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		if !lock.IsMatured(now) {
+			return shim.Error("lock " + id + " is not matured")
+		}
+		locks = append(locks, lock)
+	}
+
+	// pay locks & emit transfer events
+	claimed, err := repository.ClaimLocks(stub, recipientAddress, locks)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(claimed.String()))
+}
+
+// LockedBalanceOf is query function
+// params - address, pageSize, [bookmark]
+// Returns the locked amount of address, one page of its pending locks & the bookmark of next page
+func (cc *Controller) LockedBalanceOf(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2 or 3
+	if len(params) != 2 && len(params) != 3 {
+		return shim.Error("incorrect number of params")
+	}
+
+	address := params[0]
+
+	// check page size
+	pageSize, err := strconv.ParseInt(params[1], 10, 32)
+	if err != nil || pageSize <= 0 || pageSize > maxLockPageSize {
+		return shim.Error("pageSize must be between 1 and " + strconv.Itoa(maxLockPageSize))
+	}
+	bookmark := ""
+	if len(params) == 3 {
+		bookmark = params[2]
+	}
+
+	// get locked amount & one page of pending locks
+	locked, err := repository.GetLockedAmount(stub, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	locks, nextBookmark, err := repository.GetLockPage(stub, address, int32(pageSize), bookmark)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert locked balance to bytes for return
+	response, err := json.Marshal(model.NewLockedBalance(locked, locks, nextBookmark))
+	if err != nil {
+		return shim.Error("failed to Marshal locked balance, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
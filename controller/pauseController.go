@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"encoding/json"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Pause is invoke function that stops transfer, approve, mint & burn of token
+// only pauser can call this function
+// params - none
+func (cc *Controller) Pause(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.setPaused(stub, params, true)
+}
+
+// Unpause is invoke function that resumes paused token
+// only pauser can call this function
+// params - none
+func (cc *Controller) Unpause(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.setPaused(stub, params, false)
+}
+
+// setPaused saves pause state & emits Paused or Unpaused event
+func (cc *Controller) setPaused(stub shim.ChaincodeStubInterface, params []string, paused bool) sc.Response {
+
+	// check the number of params is 0
+	if len(params) != 0 {
+		return shim.Error("incorrect number of params")
+	}
+
+	// caller must be pauser
+	callerAddress, err := checkRole(stub, model.PauserRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check pause state is changed
+	curPaused, err := repository.IsPaused(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if curPaused == paused {
+		if paused {
+			return shim.Error("token is already paused")
+		}
+		return shim.Error("token is not paused")
+	}
+
+	// save pause state
+	err = repository.SavePaused(stub, paused)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// emit paused or unpaused event
+	eventKey := repository.PausedEventKey
+	if !paused {
+		eventKey = repository.UnpausedEventKey
+	}
+	err = repository.EmitPauseEvent(stub, eventKey, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	if paused {
+		return shim.Success([]byte("pause success"))
+	}
+	return shim.Success([]byte("unpause success"))
+}
+
+// Paused is query function
+// params - none
+// Returns true if token is paused
+func (cc *Controller) Paused(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 0
+	if len(params) != 0 {
+		return shim.Error("incorrect number of params")
+	}
+
+	paused, err := repository.IsPaused(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert paused to bytes for return
+	response, err := json.Marshal(paused)
+	if err != nil {
+		return shim.Error("failed to Marshal paused, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
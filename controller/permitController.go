@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strconv"
+
+	"github.com/erc20/identity"
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/cid"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// ecdsaSignature is ASN.1 DER structure of ECDSA signature
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// RegisterPermitKey is invoke function that registers the public key
+// which verifies permit signatures of the caller
+// the public key of the caller's certificate is registered if publicKey is not given
+// params - [publicKey(PEM)]
+func (cc *Controller) RegisterPermitKey(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 0 or 1
+	if len(params) > 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	// get caller address
+	callerAddress, err := identity.GetAddress(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get public key
+	var publicKeyPEM []byte
+	if len(params) == 1 {
+		publicKeyPEM = []byte(params[0])
+		if _, err = parseECDSAPublicKey(publicKeyPEM); err != nil {
+			return shim.Error(err.Error())
+		}
+	} else {
+		cert, err := cid.GetX509Certificate(stub)
+		if err != nil || cert == nil {
+			return shim.Error("failed to get certificate of caller")
+		}
+		if _, ok := cert.PublicKey.(*ecdsa.PublicKey); !ok {
+			return shim.Error("public key of certificate must be ECDSA")
+		}
+		publicKeyPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: cert.RawSubjectPublicKeyInfo})
+	}
+
+	// save public key
+	err = repository.SavePermitKey(stub, callerAddress, publicKeyPEM)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("registerPermitKey success"))
+}
+
+// Permit is invoke function that sets value as the allowance of spender over the owner tokens
+// with the signature of owner, so anyone can submit the approval of owner
+// the signature is ECDSA signature (base64 of ASN.1 DER) over sha256 of PermitMessage
+// with the domain returned by permitDomain
+// params - tokenName, owner's address, spender's address, value, deadline(unix time), signature
+func (cc *Controller) Permit(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 6
+	if len(params) != 6 {
+		return shim.Error("incorrect number of params")
+	}
+
+	tokenName, ownerAddress, spenderAddress, value, deadline, signature := params[0], params[1], params[2], params[3], params[4], params[5]
+
+	// permit must be signed for the token of this chaincode
+	erc20, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check value is integer & not negative
+	valueInt, err := util.ConvertToAmount("value", value)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check deadline is not passed
+	deadlineInt, err := strconv.ParseInt(deadline, 10, 64)
+	if err != nil {
+		return shim.Error("deadline must be unix time")
+	}
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if now > deadlineInt {
+		return shim.Error("permit is expired")
+	}
+
+	// get public key of owner
+	publicKeyPEM, err := repository.GetPermitKey(stub, ownerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if publicKeyPEM == nil {
+		return shim.Error("owner didn't register permit key")
+	}
+	publicKey, err := parseECDSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// make canonical message with the domain of this deployment & the current nonce of owner
+	domain, err := getPermitDomain(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	nonce, err := repository.GetNonce(stub, ownerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	message := model.NewPermitMessage(stub.GetChannelID(), domain, *erc20.GetName(), *erc20.GetSymbol(), ownerAddress, spenderAddress, valueInt.String(), nonce, deadlineInt)
+	digest, err := message.Digest()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// verify signature
+	err = verifyECDSASignature(publicKey, digest, signature)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// use nonce (prevent replay)
+	err = repository.SaveNonce(stub, ownerAddress, nonce+1)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save allowance & emit approval event
+	err = repository.SaveAllowance(stub, ownerAddress, spenderAddress, valueInt, 0)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	err = repository.EmitApprovalEvent(stub, ownerAddress, spenderAddress, valueInt)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("permit success"))
+}
+
+// Nonces is query function
+// params - owner's address
+// Returns the nonce of the next permit of owner
+func (cc *Controller) Nonces(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	nonce, err := repository.GetNonce(stub, params[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert nonce to bytes for return
+	response, err := json.Marshal(nonce)
+	if err != nil {
+		return shim.Error("failed to Marshal nonce, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// PermitDomain is query function
+// params - none
+// Returns the domain which permit messages of this deployment must have
+func (cc *Controller) PermitDomain(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 0
+	if len(params) != 0 {
+		return shim.Error("incorrect number of params")
+	}
+
+	domain, err := getPermitDomain(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(domain))
+}
+
+// getPermitDomain returns the permit domain saved at Init or error if it is not saved
+func getPermitDomain(stub shim.ChaincodeStubInterface) (string, error) {
+	domain, err := repository.GetPermitDomain(stub)
+	if err != nil {
+		return "", err
+	}
+	if len(domain) == 0 {
+		return "", errors.New("permit domain is not saved")
+	}
+
+	return domain, nil
+}
+
+// parseECDSAPublicKey converts PEM encoded public key to ECDSA public key
+func parseECDSAPublicKey(publicKeyPEM []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, errors.New("public key must be PEM encoded")
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("failed to parse public key, error: " + err.Error())
+	}
+
+	ecdsaPublicKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key must be ECDSA")
+	}
+
+	return ecdsaPublicKey, nil
+}
+
+// verifyECDSASignature verifies signature (base64 of ASN.1 DER) over digest
+func verifyECDSASignature(publicKey *ecdsa.PublicKey, digest []byte, signature string) error {
+	signatureBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return errors.New("signature must be base64 encoded")
+	}
+
+	sig := ecdsaSignature{}
+	rest, err := asn1.Unmarshal(signatureBytes, &sig)
+	if err != nil || len(rest) != 0 {
+		return errors.New("signature must be ASN.1 DER encoded")
+	}
+
+	if !ecdsa.Verify(publicKey, digest, sig.R, sig.S) {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+}
@@ -3,7 +3,10 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/erc20/model"
 	"github.com/erc20/repository"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	sc "github.com/hyperledger/fabric/protos/peer"
@@ -11,7 +14,7 @@ import (
 
 // TotalSupply is query function
 // params - tokenName
-// Returns the amount of token in existence
+// Returns the amount of token in existence (decimal string)
 func (cc *Controller) TotalSupply(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
 	// check the number of params is one
@@ -28,7 +31,7 @@ func (cc *Controller) TotalSupply(stub shim.ChaincodeStubInterface, params []str
 	}
 
 	// Convert TotalSupply to Bytes
-	totalSupplyBytes, err := json.Marshal(totalSupply)
+	totalSupplyBytes, err := json.Marshal(model.NewAmount(totalSupply))
 	if err != nil {
 		return shim.Error("failed to Marshal totalSupply, error: " + err.Error())
 	}
@@ -37,9 +40,63 @@ func (cc *Controller) TotalSupply(stub shim.ChaincodeStubInterface, params []str
 	return shim.Success(totalSupplyBytes)
 }
 
+// Decimals is query function
+// params - tokenName
+// Returns the number of decimals used to get its user representation
+func (cc *Controller) Decimals(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return shim.Error("incorrect number of parameter")
+	}
+
+	tokenName := params[0]
+
+	// Get ERC20 Metadata
+	erc20, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Convert Decimals to Bytes
+	decimalsBytes, err := json.Marshal(erc20.GetDecimals())
+	if err != nil {
+		return shim.Error("failed to Marshal decimals, error: " + err.Error())
+	}
+
+	return shim.Success(decimalsBytes)
+}
+
+// Metadata is query function
+// params - tokenName
+// Returns the name, symbol, decimals, owner, total supply & cap of token
+func (cc *Controller) Metadata(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is one
+	if len(params) != 1 {
+		return shim.Error("incorrect number of parameter")
+	}
+
+	tokenName := params[0]
+
+	// Get ERC20 Metadata
+	erc20, err := repository.GetERC20Metadata(stub, tokenName)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// Convert Metadata to Bytes
+	erc20Bytes, err := json.Marshal(erc20)
+	if err != nil {
+		return shim.Error("failed to Marshal metadata, error: " + err.Error())
+	}
+
+	return shim.Success(erc20Bytes)
+}
+
 // BalanceOf is query function
 // params - address
-// Returns the amount of tokens owned by addresss
+// Returns the amount of tokens owned by addresss & the amount locked for address
 func (cc *Controller) BalanceOf(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
 	// check the number of params is one
@@ -50,12 +107,24 @@ func (cc *Controller) BalanceOf(stub shim.ChaincodeStubInterface, params []strin
 	address := params[0]
 
 	// get Balance
-	amountBytes, err := repository.GetBalanceBytes(stub, address, true)
+	balance, err := repository.GetBalance(stub, address, true)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get locked amount
+	locked, err := repository.GetLockedAmount(stub, address)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	return shim.Success(amountBytes)
+	// convert balance to bytes for return
+	response, err := json.Marshal(model.NewBalance(balance, locked))
+	if err != nil {
+		return shim.Error("failed to Marshal balance, error: " + err.Error())
+	}
+
+	return shim.Success(response)
 }
 
 // ApprovalList is query function
@@ -87,7 +156,8 @@ func (cc *Controller) ApprovalList(stub shim.ChaincodeStubInterface, params []st
 
 // Allowance is query function
 // params - owner's address, spender's address
-// Returns the remaining amount of token to invoke {transferFrom}
+// Returns the remaining amount of token to invoke {transferFrom} & its expiry
+// (allowance of expired record is zero)
 func (cc *Controller) Allowance(stub shim.ChaincodeStubInterface, params []string) sc.Response {
 
 	// check the number of params is 2
@@ -97,12 +167,198 @@ func (cc *Controller) Allowance(stub shim.ChaincodeStubInterface, params []strin
 
 	ownerAddress, spenderAddress := params[0], params[1]
 
-	// get amount
-	amountBytes, err := repository.GetAllowanceBytes(stub, ownerAddress, spenderAddress, true)
+	// get allowance record
+	allowance, err := repository.GetAllowanceRecord(stub, ownerAddress, spenderAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert allowance record to bytes for return
+	response, err := json.Marshal(allowance)
+	if err != nil {
+		return shim.Error("failed to Marshal allowance, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// maxHolderPageSize is the maximum number of holders in one page
+const maxHolderPageSize = 100
+
+// Holders is query function
+// params - pageSize, [bookmark]
+// Returns one page of the addresses which have nonzero balance & the bookmark of next page
+func (cc *Controller) Holders(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1 or 2
+	if len(params) != 1 && len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	// check page size
+	pageSize, err := strconv.ParseInt(params[0], 10, 32)
+	if err != nil || pageSize <= 0 || pageSize > maxHolderPageSize {
+		return shim.Error("pageSize must be between 1 and " + strconv.Itoa(maxHolderPageSize))
+	}
+	bookmark := ""
+	if len(params) == 2 {
+		bookmark = params[1]
+	}
+
+	// get holders
+	holderPage, err := repository.GetHolders(stub, int32(pageSize), bookmark)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert holderPage to bytes for return
+	response, err := json.Marshal(holderPage)
+	if err != nil {
+		return shim.Error("failed to Marshal holderPage, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// HolderCount is query function
+// params - none
+// Returns the number of addresses which have nonzero balance
+func (cc *Controller) HolderCount(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 0
+	if len(params) != 0 {
+		return shim.Error("incorrect number of params")
+	}
+
+	count, err := repository.GetHolderCount(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(strconv.Itoa(count)))
+}
+
+// maxActivityPageSize is the maximum number of activities in one page
+const maxActivityPageSize = 100
+
+// TransferHistory is query function
+// params - address, pageSize, [bookmark]
+// Returns one page of the transfers, mints & burns of address (oldest first) & the bookmark of next page
+func (cc *Controller) TransferHistory(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2 or 3
+	if len(params) != 2 && len(params) != 3 {
+		return shim.Error("incorrect number of params")
+	}
+
+	address := params[0]
+	if len(address) == 0 {
+		return shim.Error("address cannot be empty")
+	}
+
+	// check page size
+	pageSize, err := strconv.ParseInt(params[1], 10, 32)
+	if err != nil || pageSize <= 0 || pageSize > maxActivityPageSize {
+		return shim.Error("pageSize must be between 1 and " + strconv.Itoa(maxActivityPageSize))
+	}
+	bookmark := ""
+	if len(params) == 3 {
+		bookmark = params[2]
+	}
+
+	// get activities
+	activityPage, err := repository.GetActivities(stub, address, int32(pageSize), bookmark)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert activityPage to bytes for return
+	response, err := json.Marshal(activityPage)
+	if err != nil {
+		return shim.Error("failed to Marshal activityPage, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// BalanceOfAt is query function
+// params - address, snapshot id or timestamp (RFC3339)
+// Returns the balance of address at snapshot or timestamp
+// (the write which set it is included for timestamp)
+func (cc *Controller) BalanceOfAt(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	address := params[0]
+
+	// get balance at snapshot
+	if snapshotID, err := strconv.ParseUint(params[1], 10, 64); err == nil {
+		balance, err := repository.GetBalanceAtSnapshot(stub, address, snapshotID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return historicalValueResponse(balance)
+	}
+
+	at, err := time.Parse(time.RFC3339, params[1])
+	if err != nil {
+		return shim.Error("snapshot id must be integer or timestamp must be RFC3339, error: " + err.Error())
+	}
+
+	// get balance at timestamp
+	balance, err := repository.GetBalanceAt(stub, address, at)
 	if err != nil {
 		return shim.Error(err.Error())
 	}
 
-	return shim.Success(amountBytes)
+	return historicalValueResponse(balance)
+}
+
+// TotalSupplyAt is query function
+// params - tokenName, snapshot id or timestamp (RFC3339)
+// Returns the total supply of token at snapshot or timestamp
+// (the write which set it is included for timestamp)
+func (cc *Controller) TotalSupplyAt(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
 
+	tokenName := params[0]
+
+	// get total supply at snapshot
+	if snapshotID, err := strconv.ParseUint(params[1], 10, 64); err == nil {
+		totalSupply, err := repository.GetTotalSupplyAtSnapshot(stub, tokenName, snapshotID)
+		if err != nil {
+			return shim.Error(err.Error())
+		}
+		return historicalValueResponse(totalSupply)
+	}
+
+	at, err := time.Parse(time.RFC3339, params[1])
+	if err != nil {
+		return shim.Error("snapshot id must be integer or timestamp must be RFC3339, error: " + err.Error())
+	}
+
+	// get total supply at timestamp
+	totalSupply, err := repository.GetTotalSupplyAt(stub, tokenName, at)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return historicalValueResponse(totalSupply)
+}
+
+// historicalValueResponse converts historicalValue to response
+func historicalValueResponse(historicalValue *model.HistoricalValue) sc.Response {
+	response, err := json.Marshal(historicalValue)
+	if err != nil {
+		return shim.Error("failed to Marshal historicalValue, error: " + err.Error())
+	}
+
+	return shim.Success(response)
 }
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"encoding/json"
+
+	"github.com/erc20/identity"
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// checkRole returns the address of the transaction creator
+// or error if the creator doesn't have role
+func checkRole(stub shim.ChaincodeStubInterface, role string) (string, error) {
+	callerAddress, err := identity.GetAddress(stub)
+	if err != nil {
+		return "", err
+	}
+
+	hasRole, err := repository.HasRole(stub, role, callerAddress)
+	if err != nil {
+		return "", err
+	}
+	if !hasRole {
+		return "", model.NewCustomError(model.AuthorizeErrorType, callerAddress, "caller doesn't have "+role+" role")
+	}
+
+	return callerAddress, nil
+}
+
+// GrantRole is invoke function that grants role to address
+// only admin can call this function
+// params - role, address
+func (cc *Controller) GrantRole(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	role, address := params[0], params[1]
+
+	// check role & address
+	if !model.IsValidRole(role) {
+		return shim.Error("unknown role: " + role)
+	}
+	if len(address) == 0 {
+		return shim.Error("address cannot be empty")
+	}
+
+	// caller must be admin
+	callerAddress, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save role
+	err = repository.SaveRole(stub, role, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// emit role granted event
+	err = repository.EmitRoleEvent(stub, repository.RoleGrantedEventKey, role, address, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("grantRole success"))
+}
+
+// RevokeRole is invoke function that revokes role from address
+// only admin can call this function, admin cannot revoke own admin role
+// params - role, address
+func (cc *Controller) RevokeRole(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	role, address := params[0], params[1]
+
+	// check role
+	if !model.IsValidRole(role) {
+		return shim.Error("unknown role: " + role)
+	}
+
+	// caller must be admin
+	callerAddress, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// prevent the token from losing every admin
+	if role == model.AdminRole && address == callerAddress {
+		return shim.Error("admin cannot revoke own admin role")
+	}
+
+	// delete role
+	err = repository.DeleteRole(stub, role, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// emit role revoked event
+	err = repository.EmitRoleEvent(stub, repository.RoleRevokedEventKey, role, address, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("revokeRole success"))
+}
+
+// HasRole is query function
+// params - role, address
+// Returns true if address has role
+func (cc *Controller) HasRole(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	role, address := params[0], params[1]
+
+	// get role
+	hasRole, err := repository.HasRole(stub, role, address)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert result to bytes for return
+	response, err := json.Marshal(hasRole)
+	if err != nil {
+		return shim.Error("failed to Marshal hasRole, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// RoleMembers is query function
+// params - role
+// Returns the list of addresses which have role
+func (cc *Controller) RoleMembers(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	role := params[0]
+
+	// get members
+	members, err := repository.GetRoleMembers(stub, role)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert members to bytes for return
+	response, err := json.Marshal(members)
+	if err != nil {
+		return shim.Error("failed to Marshal members, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// Snapshot is invoke function that takes a snapshot of every balance & the total supply
+// balances are kept lazily at the first change after the snapshot, so token doesn't need to be paused
+// only admin can call this function
+// params - none
+// Returns the id of new snapshot
+func (cc *Controller) Snapshot(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 0
+	if len(params) != 0 {
+		return shim.Error("incorrect number of params")
+	}
+
+	// caller must be admin
+	callerAddress, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// increase snapshot id
+	id, err := repository.GetSnapshotID(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	id++
+	err = repository.SaveSnapshotID(stub, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// emit snapshot event
+	err = repository.EmitSnapshotEvent(stub, id, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(strconv.FormatUint(id, 10)))
+}
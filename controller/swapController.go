@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/erc20/identity"
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// OfferSwap is invoke function that offers amountA of this token from the caller to taker
+// for amountB of the token of otherChaincode, which taker can accept by swap
+// the new offer replaces the previous offer of the caller to taker
+// params - caller's address, taker's address, otherChaincode, amountA, amountB
+func (cc *Controller) OfferSwap(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 5
+	if len(params) != 5 {
+		return shim.Error("incorrect number of params")
+	}
+
+	callerAddress, takerAddress, otherChaincode := params[0], params[1], params[2]
+	if len(takerAddress) == 0 || callerAddress == takerAddress {
+		return shim.Error("invalid taker: " + takerAddress)
+	}
+	if len(otherChaincode) == 0 {
+		return shim.Error("otherChaincode cannot be empty")
+	}
+
+	// caller must be the transaction creator
+	err := identity.CheckAddress(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check amounts are integer & positive
+	amountAInt, err := util.ConvertToPositive("amountA", params[3])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	amountBInt, err := util.ConvertToPositive("amountB", params[4])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// caller & taker cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, callerAddress, takerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save offer
+	err = repository.SaveSwapOffer(stub, model.NewSwapOffer(callerAddress, takerAddress, otherChaincode, amountAInt, amountBInt))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("offerSwap success"))
+}
+
+// CancelSwapOffer is invoke function that deletes the offer of the caller to taker
+// params - caller's address, taker's address
+func (cc *Controller) CancelSwapOffer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	callerAddress, takerAddress := params[0], params[1]
+
+	// caller must be the transaction creator
+	err := identity.CheckAddress(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// delete offer
+	err = repository.DeleteSwapOffer(stub, callerAddress, takerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("cancelSwapOffer success"))
+}
+
+// SwapOffer is query function
+// params - counterparty's address, taker's address
+// Returns the offer of counterparty to taker
+func (cc *Controller) SwapOffer(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	offer, err := getSwapOffer(stub, params[0], params[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert offer to bytes for return
+	response, err := json.Marshal(offer)
+	if err != nil {
+		return shim.Error("failed to Marshal swap offer, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
+
+// Swap is invoke function that accepts the offer of counterparty to the caller
+// & exchanges amountA of this token from counterparty for amountB of the token of otherChaincode
+// from the caller in one transaction
+// otherChaincode, amountA & amountB must match the offer, which is consumed by the swap
+// this token moves to the caller by transfer from counterparty,
+// and the other token moves to counterparty by transfer of otherChaincode
+// otherChaincode must be added to trusted chaincodes by admin
+// the whole transaction fails if either transfer fails
+// params - otherChaincode, caller's address, counterparty's address, amountA, amountB
+func (cc *Controller) Swap(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 5
+	if len(params) != 5 {
+		return shim.Error("incorrect number of params")
+	}
+
+	otherChaincode, callerAddress, counterpartyAddress, amountA, amountB := params[0], params[1], params[2], params[3], params[4]
+	if callerAddress == counterpartyAddress {
+		return shim.Error("caller cannot swap with itself")
+	}
+
+	// caller must be the transaction creator
+	err := identity.CheckAddress(stub, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check amounts are integer & positive
+	amountAInt, err := util.ConvertToPositive("amountA", amountA)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	amountBInt, err := util.ConvertToPositive("amountB", amountB)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// swap must match the offer of counterparty to the caller
+	offer, err := getSwapOffer(stub, counterpartyAddress, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !offer.Matches(otherChaincode, amountAInt, amountBInt) {
+		return shim.Error("swap doesn't match the offer of " + counterpartyAddress)
+	}
+
+	// caller & counterparty cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, callerAddress, counterpartyAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// consume offer
+	err = repository.DeleteSwapOffer(stub, counterpartyAddress, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// move this token from counterparty to the caller & emit transfer event
+	err = repository.Transfer(stub, counterpartyAddress, callerAddress, offer.AmountA.Int(), "swap")
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// move the other token from the caller to counterparty
+	err = transferOtherToken(stub, offer.OtherChaincode, callerAddress, counterpartyAddress, offer.AmountB.Int().String())
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("swap success"))
+}
+
+// getSwapOffer returns the offer of counterparty to taker or error if it doesn't exist
+func getSwapOffer(stub shim.ChaincodeStubInterface, counterparty, taker string) (*model.SwapOffer, error) {
+	offer, err := repository.GetSwapOffer(stub, counterparty, taker)
+	if err != nil {
+		return nil, err
+	}
+	if offer == nil {
+		return nil, errors.New(counterparty + " has no swap offer to " + taker)
+	}
+
+	return offer, nil
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"encoding/json"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// AddTrustedChaincode is invoke function that approves the token chaincode
+// which transferOtherToken & swap can call on the channel of this chaincode
+// only admin can call this function
+// params - chaincode name
+func (cc *Controller) AddTrustedChaincode(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.setTrustedChaincode(stub, params, true)
+}
+
+// RemoveTrustedChaincode is invoke function that removes the approval of the token chaincode
+// only admin can call this function
+// params - chaincode name
+func (cc *Controller) RemoveTrustedChaincode(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+	return cc.setTrustedChaincode(stub, params, false)
+}
+
+// setTrustedChaincode saves the approval of the token chaincode & emits its event
+func (cc *Controller) setTrustedChaincode(stub shim.ChaincodeStubInterface, params []string, trusted bool) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	name := params[0]
+	if len(name) == 0 {
+		return shim.Error("chaincode name cannot be empty")
+	}
+
+	// caller must be admin
+	callerAddress, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save approval
+	err = repository.SaveTrustedChaincode(stub, name, trusted)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// emit trustedChaincodeAdded or trustedChaincodeRemoved event
+	eventKey := repository.TrustedChaincodeRemovedEventKey
+	if trusted {
+		eventKey = repository.TrustedChaincodeAddedEventKey
+	}
+	err = repository.EmitTrustedChaincodeEvent(stub, eventKey, name, callerAddress)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("trusted chaincode is changed"))
+}
+
+// TrustedChaincodes is query function
+// params - none
+// Returns every trusted chaincode
+func (cc *Controller) TrustedChaincodes(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 0
+	if len(params) != 0 {
+		return shim.Error("incorrect number of params")
+	}
+
+	trustedChaincodes, err := repository.GetTrustedChaincodes(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// convert trustedChaincodes to bytes for return
+	response, err := json.Marshal(trustedChaincodes)
+	if err != nil {
+		return shim.Error("failed to Marshal trustedChaincodes, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
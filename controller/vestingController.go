@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/erc20/repository"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// CreateVesting is invoke function that moves total tokens of the caller into new schedule
+// & releases them to beneficiary linearly from start + cliff to start + duration
+// only admin can call this function
+// params - beneficiary's address, total, start (unix time), cliff (seconds), duration (seconds), revocable (true or false)
+// Returns the id of new schedule
+func (cc *Controller) CreateVesting(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 6
+	if len(params) != 6 {
+		return shim.Error("incorrect number of params")
+	}
+
+	beneficiary := params[0]
+	if len(beneficiary) == 0 {
+		return shim.Error("invalid beneficiary: " + beneficiary)
+	}
+
+	// check total is positive
+	totalInt, err := util.ConvertToPositive("total", params[1])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// check schedule
+	start, err := strconv.ParseInt(params[2], 10, 64)
+	if err != nil || start < 0 {
+		return shim.Error("start must be unix time")
+	}
+	cliff, err := strconv.ParseInt(params[3], 10, 64)
+	if err != nil || cliff < 0 {
+		return shim.Error("cliff must be zero or positive seconds")
+	}
+	duration, err := strconv.ParseInt(params[4], 10, 64)
+	if err != nil || duration <= 0 {
+		return shim.Error("duration must be positive seconds")
+	}
+	if cliff > duration {
+		return shim.Error("cliff cannot be longer than duration")
+	}
+	// start + duration (and start + cliff) must not overflow
+	if start > math.MaxInt64-duration {
+		return shim.Error("start + duration is out of range")
+	}
+	revocable, err := strconv.ParseBool(params[5])
+	if err != nil {
+		return shim.Error("revocable must be true or false")
+	}
+
+	// caller must be admin
+	callerAddress, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// caller & beneficiary cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, callerAddress, beneficiary)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// move total tokens of the caller into schedule
+	id := stub.GetTxID()
+	err = repository.Escrow(stub, callerAddress, repository.VestingEscrow(id), totalInt, "vesting "+id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save schedule
+	schedule := model.NewVestingSchedule(id, beneficiary, callerAddress, totalInt, start, cliff, duration, revocable)
+	err = repository.SaveVestingSchedule(stub, schedule)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(id))
+}
+
+// Release is invoke function that pays the vested & unreleased tokens of schedule to beneficiary
+// anyone can call this function since tokens are paid only to beneficiary
+// params - beneficiary's address, schedule id
+// Returns the released amount
+func (cc *Controller) Release(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	beneficiary, id := params[0], params[1]
+
+	// get schedule
+	schedule, err := repository.GetVestingSchedule(stub, beneficiary, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// beneficiary cannot be frozen or blacklisted
+	err = checkNotRestricted(stub, beneficiary)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// calculate releasable amount
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	releasable := schedule.ReleasableAmount(now)
+	if releasable.Sign() <= 0 {
+		return shim.Error("no tokens are due")
+	}
+
+	// pay releasable amount from schedule & emit transfer event
+	err = repository.ReleaseEscrow(stub, repository.VestingEscrow(id), beneficiary, releasable, "vesting "+id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// save released amount
+	schedule.Released.Int().Add(schedule.Released.Int(), releasable)
+	err = repository.SaveVestingSchedule(stub, schedule)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte(releasable.String()))
+}
+
+// RevokeVesting is invoke function that stops revocable schedule
+// the tokens vested at revocation can still be released, and the unvested tokens are returned to the creator
+// only admin can call this function
+// params - beneficiary's address, schedule id
+func (cc *Controller) RevokeVesting(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 2
+	if len(params) != 2 {
+		return shim.Error("incorrect number of params")
+	}
+
+	beneficiary, id := params[0], params[1]
+
+	// caller must be admin
+	_, err := checkRole(stub, model.AdminRole)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// get schedule
+	schedule, err := repository.GetVestingSchedule(stub, beneficiary, id)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if !schedule.Revocable {
+		return shim.Error("vesting schedule is not revocable")
+	}
+	if schedule.Revoked {
+		return shim.Error("vesting schedule is already revoked")
+	}
+
+	// stop schedule at the vested amount
+	err = revokeVesting(stub, schedule)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	return shim.Success([]byte("revokeVesting success"))
+}
+
+// revokeVesting saves schedule as revoked & returns the unvested tokens to the creator
+func revokeVesting(stub shim.ChaincodeStubInterface, schedule *model.VestingSchedule) error {
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return err
+	}
+	vested := schedule.VestedAmount(now)
+	unvested := new(big.Int).Sub(schedule.Total.Int(), vested)
+
+	// return unvested tokens from schedule & emit transfer event
+	// (creator cannot be frozen or blacklisted)
+	if unvested.Sign() > 0 {
+		err = checkNotRestricted(stub, schedule.Creator)
+		if err != nil {
+			return err
+		}
+		err = repository.ReleaseEscrow(stub, repository.VestingEscrow(schedule.ID), schedule.Creator, unvested, "vesting revoked "+schedule.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	schedule.Total = model.NewAmount(vested)
+	schedule.Revoked = true
+	return repository.SaveVestingSchedule(stub, schedule)
+}
+
+// VestingSchedules is query function
+// params - beneficiary's address
+// Returns every schedule of beneficiary with its vested, unvested & releasable amount
+func (cc *Controller) VestingSchedules(stub shim.ChaincodeStubInterface, params []string) sc.Response {
+
+	// check the number of params is 1
+	if len(params) != 1 {
+		return shim.Error("incorrect number of params")
+	}
+
+	beneficiary := params[0]
+
+	// get schedules
+	schedules, err := repository.GetVestingSchedules(stub, beneficiary)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+
+	// calculate vested amount at transaction time
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	statuses := []*model.VestingStatus{}
+	for _, schedule := range schedules {
+		statuses = append(statuses, model.NewVestingStatus(schedule, now))
+	}
+
+	// convert statuses to bytes for return
+	response, err := json.Marshal(statuses)
+	if err != nil {
+		return shim.Error("failed to Marshal vesting schedules, error: " + err.Error())
+	}
+
+	return shim.Success(response)
+}
@@ -11,7 +11,9 @@ const PEER_NAME = 'peer0.org1.example.com'
 const CHANNEL_NAME = 'mychannel'
 
 const CHAINCODE_ID = 'erc20-transfer'
-const CHAINCODE_EVENT = 'transferEvent'
+// every event of a transaction is emitted together in one envelope event
+const CHAINCODE_EVENT = 'erc20Events'
+const COMPLIANCE_EVENTS = ['frozenEvent', 'unfrozenEvent', 'blacklistedEvent', 'unblacklistedEvent', 'frozenWipedEvent']
 
 const CRYPTO_CONTENT = {
     privateKey: '/Users/kyung/.fabric-vscode/environments/1 Org Local Fabric/wallets/Org1/org1Admin/keystore/5499a068111f5fd054300e0ee6d67447efdbcf9426cfdbd1fc83ee0b76e3ff78_sk',
@@ -29,7 +31,15 @@ async function subscribeEvent() {
 
             // onEvent
             (chaincodeEvent)=>{
-                console.log(`chaincode event emiited: ${chaincodeEvent.chaincode_id}  ${chaincodeEvent.event_name}  ${new String(chaincodeEvent.payload)}`)
+                const envelope = JSON.parse(new String(chaincodeEvent.payload))
+                for (const event of envelope.events) {
+                    const payload = JSON.stringify(event.payload)
+                    if (COMPLIANCE_EVENTS.includes(event.type)) {
+                        console.log(`[COMPLIANCE ALERT] ${envelope.txId}  ${event.type}  ${payload}`)
+                    } else {
+                        console.log(`chaincode event emiited: ${chaincodeEvent.chaincode_id}  ${envelope.txId}  ${event.type}  ${payload}`)
+                    }
+                }
             },
             // onError
             (err)=>{
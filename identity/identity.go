@@ -0,0 +1,53 @@
+package identity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/core/chaincode/shim/ext/cid"
+)
+
+// addressLength is the number of hash bytes used for an address (same as ethereum)
+const addressLength = 20
+
+// GetAddress returns the address of the transaction creator
+// address is hex(sha256(MSP ID + public key of the creator's X.509 certificate)[:20])
+func GetAddress(stub shim.ChaincodeStubInterface) (string, error) {
+	// get creator's MSP ID
+	mspID, err := cid.GetMSPID(stub)
+	if err != nil {
+		return "", model.NewCustomError(model.GetCreatorErrorType, "mspID", err.Error())
+	}
+
+	// get creator's certificate
+	cert, err := cid.GetX509Certificate(stub)
+	if err != nil {
+		return "", model.NewCustomError(model.GetCreatorErrorType, "certificate", err.Error())
+	}
+	if cert == nil {
+		return "", model.NewCustomError(model.GetCreatorErrorType, "certificate", "creator has no X.509 certificate")
+	}
+
+	// hash MSP ID & public key
+	hash := sha256.New()
+	hash.Write([]byte(mspID))
+	hash.Write(cert.RawSubjectPublicKeyInfo)
+
+	return hex.EncodeToString(hash.Sum(nil)[:addressLength]), nil
+}
+
+// CheckAddress returns error if address is not the address of the transaction creator
+func CheckAddress(stub shim.ChaincodeStubInterface, address string) error {
+	creatorAddress, err := GetAddress(stub)
+	if err != nil {
+		return err
+	}
+
+	if creatorAddress != address {
+		return model.NewCustomError(model.AuthorizeErrorType, address, "address is not the transaction creator")
+	}
+
+	return nil
+}
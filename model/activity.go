@@ -0,0 +1,25 @@
+package model
+
+// directions of activity
+const (
+	ActivityIn  = "in"
+	ActivityOut = "out"
+)
+
+// Activity is one token movement of an address
+// Counterparty is the other side of the transfer ("admin" for mint, burn address for burn)
+type Activity struct {
+	TxID         string `json:"txID"`
+	Timestamp    string `json:"timestamp"`
+	Direction    string `json:"direction"`
+	Counterparty string `json:"counterparty"`
+	Amount       string `json:"amount"`
+	Memo         string `json:"memo,omitempty"`
+}
+
+// ActivityPage is one page of the activities of an address (oldest first)
+// Bookmark is passed to the next query to get the next page (empty on the last page)
+type ActivityPage struct {
+	Activities []Activity `json:"activities"`
+	Bookmark   string     `json:"bookmark"`
+}
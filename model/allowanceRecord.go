@@ -0,0 +1,23 @@
+package model
+
+import "math/big"
+
+// AllowanceRecord is the definition of allowance saved in world state
+// ExpiresAt is unix time (seconds) after which the allowance is zero, 0 means it never expires
+type AllowanceRecord struct {
+	Approval
+	ExpiresAt int64 `json:"expiresAt"`
+	Expired   bool  `json:"expired"`
+}
+
+func NewAllowanceRecord(owner, spender string, allowance *big.Int, expiresAt int64) *AllowanceRecord {
+	return &AllowanceRecord{
+		Approval:  *NewApproval(owner, spender, allowance),
+		ExpiresAt: expiresAt,
+	}
+}
+
+// IsExpired returns true if the allowance is expired at now (unix time in seconds)
+func (record *AllowanceRecord) IsExpired(now int64) bool {
+	return record.ExpiresAt != 0 && now >= record.ExpiresAt
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+// Amount is the JSON format of token amount
+// amount is written as decimal string, since JSON number loses precision above 2^53 in JavaScript clients
+// decimal string is read in the same format, and bare number is also read for records saved as number before
+type Amount big.Int
+
+// NewAmount returns amount as Amount (nil if amount is nil)
+func NewAmount(amount *big.Int) *Amount {
+	return (*Amount)(amount)
+}
+
+// Int returns amount as big.Int (nil if amount is nil)
+func (amount *Amount) Int() *big.Int {
+	return (*big.Int)(amount)
+}
+
+func (amount *Amount) MarshalJSON() ([]byte, error) {
+	if amount == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(amount.Int().String())
+}
+
+func (amount *Amount) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	value := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := amount.Int().SetString(value, 10); !ok {
+		return errors.New("amount must be integer: " + value)
+	}
+	return nil
+}
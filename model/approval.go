@@ -1,16 +1,18 @@
 package model
 
+import "math/big"
+
 // Approval is the definition of Approval Event & Data format
 type Approval struct {
-	Owner     string `json:"owner"`
-	Spender   string `json:"spender"`
-	Allowance int    `json:"allowance"`
+	Owner     string  `json:"owner"`
+	Spender   string  `json:"spender"`
+	Allowance *Amount `json:"allowance"`
 }
 
-func NewApproval(owner, spender string, allowance int) *Approval {
+func NewApproval(owner, spender string, allowance *big.Int) *Approval {
 	return &Approval{
 		Owner:     owner,
 		Spender:   spender,
-		Allowance: allowance,
+		Allowance: NewAmount(allowance),
 	}
 }
@@ -0,0 +1,18 @@
+package model
+
+import "math/big"
+
+// ComplianceEvent is the event definition of Frozen, Unfrozen, Blacklisted, Unblacklisted & FrozenWiped
+type ComplianceEvent struct {
+	Account string  `json:"account"`
+	Sender  string  `json:"sender"`
+	Amount  *Amount `json:"amount,omitempty"`
+}
+
+func NewComplianceEvent(account, sender string, amount *big.Int) *ComplianceEvent {
+	return &ComplianceEvent{
+		Account: account,
+		Sender:  sender,
+		Amount:  NewAmount(amount),
+	}
+}
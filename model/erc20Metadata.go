@@ -1,19 +1,25 @@
 package model
 
+import "math/big"
+
 // ERC20Metadata is the definition of Token Meta Info
+// Cap is the maximum total supply (nil means no cap)
 type ERC20Metadata struct {
-	Name        string `json:"name"`
-	Symbol      string `json:"symbol"`
-	Owner       string `json:"owner"`
-	TotalSupply uint64 `json:"totalSupply"`
+	Name        string  `json:"name"`
+	Symbol      string  `json:"symbol"`
+	Decimals    uint8   `json:"decimals"`
+	Owner       string  `json:"owner"`
+	TotalSupply *Amount `json:"totalSupply"`
+	Cap         *Amount `json:"cap,omitempty"`
 }
 
-func NewERC20MetaData(name, symbol, owner string, totalSupply uint64) *ERC20Metadata {
+func NewERC20MetaData(name, symbol string, decimals uint8, owner string, totalSupply *big.Int) *ERC20Metadata {
 	return &ERC20Metadata{
 		Name:        name,
 		Symbol:      symbol,
+		Decimals:    decimals,
 		Owner:       owner,
-		TotalSupply: totalSupply,
+		TotalSupply: NewAmount(totalSupply),
 	}
 }
 
@@ -25,10 +31,23 @@ func (erc20 *ERC20Metadata) GetSymbol() *string {
 	return &erc20.Symbol
 }
 
+func (erc20 *ERC20Metadata) GetDecimals() *uint8 {
+	return &erc20.Decimals
+}
+
 func (erc20 *ERC20Metadata) GetOwner() *string {
 	return &erc20.Owner
 }
 
-func (erc20 *ERC20Metadata) GetTotalSupply() *uint64 {
-	return &erc20.TotalSupply
+func (erc20 *ERC20Metadata) GetTotalSupply() *big.Int {
+	return erc20.TotalSupply.Int()
+}
+
+func (erc20 *ERC20Metadata) GetCap() *big.Int {
+	return erc20.Cap.Int()
+}
+
+// IsCapExceeded returns true if totalSupply is greater than the cap
+func (erc20 *ERC20Metadata) IsCapExceeded(totalSupply *big.Int) bool {
+	return erc20.Cap != nil && totalSupply.Cmp(erc20.Cap.Int()) > 0
 }
@@ -7,11 +7,17 @@ const (
 	UnMarshalErrorType                   = "UnMarshal"
 	ConvertErrorType                     = "Convert"
 	PutStateErrorType                    = "PutState"
+	DelStateErrorType                    = "DelState"
 	GetStateErrorType                    = "GetState"
 	SetEventErrorType                    = "SetEvent"
 	CreateCompositeKeyErrorType          = "CreateCompositeKey"
 	GetStatePartialCompositeKeyErrorType = "GetStatePartialCompositeKey"
+	GetStateByRangeErrorType             = "GetStateByRange"
+	GetHistoryForKeyErrorType            = "GetHistoryForKey"
 	SpliteCompositeKeyErrorType          = "SpliteCompositeKey"
+	GetCreatorErrorType                  = "GetCreator"
+	GetTxTimestampErrorType              = "GetTxTimestamp"
+	AuthorizeErrorType                   = "Authorize"
 )
 
 type CustomError struct {
@@ -0,0 +1,24 @@
+package model
+
+import "encoding/json"
+
+// Event is the definition of an event raised during an invocation
+// Type is the event name (e.g. transferEvent) and Payload is the event data
+type Event struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// EventEnvelope is the definition of the chaincode event
+// which carries every event raised during an invocation in order
+type EventEnvelope struct {
+	TxID   string  `json:"txId"`
+	Events []Event `json:"events"`
+}
+
+func NewEventEnvelope(txID string, events []Event) *EventEnvelope {
+	return &EventEnvelope{
+		TxID:   txID,
+		Events: events,
+	}
+}
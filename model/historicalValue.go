@@ -0,0 +1,9 @@
+package model
+
+// HistoricalValue is the value of a key at a point in time
+// TxID & Timestamp are of the write which set the value (empty if the key was never written)
+type HistoricalValue struct {
+	Value     string `json:"value"`
+	TxID      string `json:"txID,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
+}
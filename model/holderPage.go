@@ -0,0 +1,15 @@
+package model
+
+// HolderPage is one page of token holders
+// Bookmark is passed to the next query to get the next page (empty on the last page)
+type HolderPage struct {
+	Holders  []string `json:"holders"`
+	Bookmark string   `json:"bookmark"`
+}
+
+func NewHolderPage(holders []string, bookmark string) *HolderPage {
+	return &HolderPage{
+		Holders:  holders,
+		Bookmark: bookmark,
+	}
+}
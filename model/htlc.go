@@ -0,0 +1,38 @@
+package model
+
+import "math/big"
+
+// states of HTLC
+const (
+	HTLCLocked   = "locked"
+	HTLCClaimed  = "claimed"
+	HTLCRefunded = "refunded"
+)
+
+// HTLC is the definition of hashed timelock contract
+// Recipient can claim Amount with the preimage of Hashlock (hex of SHA-256) before Timelock (unix time in seconds),
+// and Sender can refund it from Timelock
+// the HTLC holds Amount while it is locked, the tokens are not in any balance
+// Preimage (hex) is saved when claimed, so the counterparty of the swap can read it
+type HTLC struct {
+	ID        string  `json:"id"`
+	Sender    string  `json:"sender"`
+	Recipient string  `json:"recipient"`
+	Amount    *Amount `json:"amount"`
+	Hashlock  string  `json:"hashlock"`
+	Timelock  int64   `json:"timelock"`
+	State     string  `json:"state"`
+	Preimage  string  `json:"preimage,omitempty"`
+}
+
+func NewHTLC(id, sender, recipient string, amount *big.Int, hashlock string, timelock int64) *HTLC {
+	return &HTLC{
+		ID:        id,
+		Sender:    sender,
+		Recipient: recipient,
+		Amount:    NewAmount(amount),
+		Hashlock:  hashlock,
+		Timelock:  timelock,
+		State:     HTLCLocked,
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+// StateKV is one key & value of world state
+type StateKV struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// StatePage is one page of world state
+// Bookmark is passed to the next query to get the next page (empty on the last page)
+type StatePage struct {
+	States   []StateKV `json:"states"`
+	Bookmark string    `json:"bookmark"`
+}
+
+// KeyModification is one modification in the history of a key
+type KeyModification struct {
+	TxID      string `json:"txID"`
+	Timestamp string `json:"timestamp"`
+	IsDelete  bool   `json:"isDelete"`
+	Value     string `json:"value"`
+}
+
+// TransactionInfo is the context of the current transaction
+type TransactionInfo struct {
+	TxID      string   `json:"txID"`
+	ChannelID string   `json:"channelID"`
+	Timestamp string   `json:"timestamp"`
+	MSPID     string   `json:"mspID"`
+	Address   string   `json:"address"`
+	Function  string   `json:"function"`
+	Params    []string `json:"params"`
+}
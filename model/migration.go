@@ -0,0 +1,19 @@
+package model
+
+// MigrationResult is the list of flat keys moved by key migration
+// Skipped is the list of flat keys which are not metadata or balance of address (not changed)
+// Bookmark is the flat key to continue migration from (empty when every flat key is examined)
+type MigrationResult struct {
+	Metadata []string `json:"metadata"`
+	Balances []string `json:"balances"`
+	Skipped  []string `json:"skipped"`
+	Bookmark string   `json:"bookmark"`
+}
+
+func NewMigrationResult() *MigrationResult {
+	return &MigrationResult{
+		Metadata: []string{},
+		Balances: []string{},
+		Skipped:  []string{},
+	}
+}
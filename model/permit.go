@@ -0,0 +1,47 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+)
+
+// PermitMessage is the definition of the message signed by owner for permit
+// the JSON encoding of PermitMessage (fields in this order) is the canonical message
+// Domain (txID of Init) binds the permit to one deployment, so it cannot be replayed on other deployments
+// of the channel, and TokenName & Symbol bind it to one token
+type PermitMessage struct {
+	ChannelID string `json:"channelId"`
+	Domain    string `json:"domain"`
+	TokenName string `json:"tokenName"`
+	Symbol    string `json:"symbol"`
+	Owner     string `json:"owner"`
+	Spender   string `json:"spender"`
+	Value     string `json:"value"`
+	Nonce     uint64 `json:"nonce"`
+	Deadline  int64  `json:"deadline"`
+}
+
+func NewPermitMessage(channelID, domain, tokenName, symbol, owner, spender, value string, nonce uint64, deadline int64) *PermitMessage {
+	return &PermitMessage{
+		ChannelID: channelID,
+		Domain:    domain,
+		TokenName: tokenName,
+		Symbol:    symbol,
+		Owner:     owner,
+		Spender:   spender,
+		Value:     value,
+		Nonce:     nonce,
+		Deadline:  deadline,
+	}
+}
+
+// Digest returns sha256 hash of the canonical message
+func (message *PermitMessage) Digest() ([]byte, error) {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return nil, NewCustomError(MarshalErrorType, "permitMessage", err.Error())
+	}
+
+	digest := sha256.Sum256(messageBytes)
+	return digest[:], nil
+}
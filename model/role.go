@@ -0,0 +1,37 @@
+package model
+
+// roles of the token
+const (
+	AdminRole  = "admin"
+	MinterRole = "minter"
+	BurnerRole = "burner"
+	PauserRole = "pauser"
+)
+
+// Roles is the list of all roles
+var Roles = []string{AdminRole, MinterRole, BurnerRole, PauserRole}
+
+// IsValidRole returns true if role is one of Roles
+func IsValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleEvent is the event definition of RoleGranted & RoleRevoked
+type RoleEvent struct {
+	Role    string `json:"role"`
+	Account string `json:"account"`
+	Sender  string `json:"sender"`
+}
+
+func NewRoleEvent(role, account, sender string) *RoleEvent {
+	return &RoleEvent{
+		Role:    role,
+		Account: account,
+		Sender:  sender,
+	}
+}
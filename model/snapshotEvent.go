@@ -0,0 +1,14 @@
+package model
+
+// SnapshotEvent is the event definition of Snapshot
+type SnapshotEvent struct {
+	ID     uint64 `json:"id"`
+	Sender string `json:"sender"`
+}
+
+func NewSnapshotEvent(id uint64, sender string) *SnapshotEvent {
+	return &SnapshotEvent{
+		ID:     id,
+		Sender: sender,
+	}
+}
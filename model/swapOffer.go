@@ -0,0 +1,29 @@
+package model
+
+import "math/big"
+
+// SwapOffer is the offer of Counterparty to give AmountA of this token to Taker
+// for AmountB of the token of OtherChaincode
+// Counterparty creates the offer, and Taker consumes it by swap
+type SwapOffer struct {
+	Counterparty   string  `json:"counterparty"`
+	Taker          string  `json:"taker"`
+	OtherChaincode string  `json:"otherChaincode"`
+	AmountA        *Amount `json:"amountA"`
+	AmountB        *Amount `json:"amountB"`
+}
+
+func NewSwapOffer(counterparty, taker, otherChaincode string, amountA, amountB *big.Int) *SwapOffer {
+	return &SwapOffer{
+		Counterparty:   counterparty,
+		Taker:          taker,
+		OtherChaincode: otherChaincode,
+		AmountA:        NewAmount(amountA),
+		AmountB:        NewAmount(amountB),
+	}
+}
+
+// Matches returns true if the offer has otherChaincode, amountA & amountB
+func (offer *SwapOffer) Matches(otherChaincode string, amountA, amountB *big.Int) bool {
+	return offer.OtherChaincode == otherChaincode && offer.AmountA.Int().Cmp(amountA) == 0 && offer.AmountB.Int().Cmp(amountB) == 0
+}
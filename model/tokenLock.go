@@ -0,0 +1,58 @@
+package model
+
+import "math/big"
+
+// TokenLock is the definition of tokens escrowed for recipient until UnlockTime (unix time in seconds)
+// the lock holds Amount until it is claimed, the tokens are not in any balance
+type TokenLock struct {
+	ID         string  `json:"id"`
+	Sender     string  `json:"sender"`
+	Recipient  string  `json:"recipient"`
+	Amount     *Amount `json:"amount"`
+	UnlockTime int64   `json:"unlockTime"`
+}
+
+func NewTokenLock(id, sender, recipient string, amount *big.Int, unlockTime int64) *TokenLock {
+	return &TokenLock{
+		ID:         id,
+		Sender:     sender,
+		Recipient:  recipient,
+		Amount:     NewAmount(amount),
+		UnlockTime: unlockTime,
+	}
+}
+
+// IsMatured returns true if the lock can be claimed at now
+func (lock *TokenLock) IsMatured(now int64) bool {
+	return now >= lock.UnlockTime
+}
+
+// LockedBalance is one page of the pending locks of an address
+// Locked is the amount of every pending lock (matured locks are included until claimed)
+// Bookmark is passed to the next query to get the next page (empty on the last page)
+type LockedBalance struct {
+	Locked   *Amount      `json:"locked"`
+	Locks    []*TokenLock `json:"locks"`
+	Bookmark string       `json:"bookmark"`
+}
+
+func NewLockedBalance(locked *big.Int, locks []*TokenLock, bookmark string) *LockedBalance {
+	return &LockedBalance{
+		Locked:   NewAmount(locked),
+		Locks:    locks,
+		Bookmark: bookmark,
+	}
+}
+
+// Balance is the spendable balance & the locked amount of an address
+type Balance struct {
+	Balance *Amount `json:"balance"`
+	Locked  *Amount `json:"locked"`
+}
+
+func NewBalance(balance, locked *big.Int) *Balance {
+	return &Balance{
+		Balance: NewAmount(balance),
+		Locked:  NewAmount(locked),
+	}
+}
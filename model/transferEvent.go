@@ -1,16 +1,18 @@
 package model
 
+import "math/big"
+
 // TransferEvent is the event definition of Transfer
 type TransferEvent struct {
-	Sender    string `json:"sender"`
-	Recipient string `json:"recipient"`
-	Amount    int    `json:"amount"`
+	Sender    string  `json:"sender"`
+	Recipient string  `json:"recipient"`
+	Amount    *Amount `json:"amount"`
 }
 
-func NewTransferEvent(sender, recipient string, amount int) *TransferEvent {
+func NewTransferEvent(sender, recipient string, amount *big.Int) *TransferEvent {
 	return &TransferEvent{
 		Sender:    sender,
 		Recipient: recipient,
-		Amount:    amount,
+		Amount:    NewAmount(amount),
 	}
 }
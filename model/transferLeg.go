@@ -0,0 +1,8 @@
+package model
+
+// TransferLeg is the definition of one recipient of batch transfer
+// Amount is decimal string to carry amounts larger than JSON numbers
+type TransferLeg struct {
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"`
+}
@@ -0,0 +1,36 @@
+package model
+
+// TrustedChaincode is the definition of external token chaincode approved by admin
+// (trusted chaincode is called on the channel of this chaincode, since calls to other channels cannot write)
+type TrustedChaincode struct {
+	Name string `json:"name"`
+}
+
+// TrustedChaincodeEvent is the event definition of adding or removing trusted chaincode by Sender
+type TrustedChaincodeEvent struct {
+	Name   string `json:"name"`
+	Sender string `json:"sender"`
+}
+
+func NewTrustedChaincodeEvent(name, sender string) *TrustedChaincodeEvent {
+	return &TrustedChaincodeEvent{
+		Name:   name,
+		Sender: sender,
+	}
+}
+
+// ExternalCallEvent is the audit event definition of the successful call to external chaincode
+// failed or refused calls are not audited, since they fail the transaction and its events are discarded
+type ExternalCallEvent struct {
+	Caller    string `json:"caller"`
+	Chaincode string `json:"chaincode"`
+	Function  string `json:"function"`
+}
+
+func NewExternalCallEvent(caller, chaincode, function string) *ExternalCallEvent {
+	return &ExternalCallEvent{
+		Caller:    caller,
+		Chaincode: chaincode,
+		Function:  function,
+	}
+}
@@ -0,0 +1,72 @@
+package model
+
+import "math/big"
+
+// VestingSchedule is the definition of tokens escrowed for beneficiary & released linearly
+// nothing is vested before Start + Cliff, and everything is vested at Start + Duration
+// (Start is unix time in seconds, Cliff & Duration are seconds)
+// the schedule holds its unreleased tokens (Total - Released), they are not in any balance
+// Total of revoked schedule is the amount vested at revocation
+type VestingSchedule struct {
+	ID          string  `json:"id"`
+	Beneficiary string  `json:"beneficiary"`
+	Creator     string  `json:"creator"`
+	Total       *Amount `json:"total"`
+	Released    *Amount `json:"released"`
+	Start       int64   `json:"start"`
+	Cliff       int64   `json:"cliff"`
+	Duration    int64   `json:"duration"`
+	Revocable   bool    `json:"revocable"`
+	Revoked     bool    `json:"revoked"`
+}
+
+func NewVestingSchedule(id, beneficiary, creator string, total *big.Int, start, cliff, duration int64, revocable bool) *VestingSchedule {
+	return &VestingSchedule{
+		ID:          id,
+		Beneficiary: beneficiary,
+		Creator:     creator,
+		Total:       NewAmount(total),
+		Released:    NewAmount(big.NewInt(0)),
+		Start:       start,
+		Cliff:       cliff,
+		Duration:    duration,
+		Revocable:   revocable,
+	}
+}
+
+// VestedAmount returns the amount vested at now (including the released amount)
+func (schedule *VestingSchedule) VestedAmount(now int64) *big.Int {
+	if schedule.Revoked || now >= schedule.Start+schedule.Duration {
+		return new(big.Int).Set(schedule.Total.Int())
+	}
+	if now < schedule.Start+schedule.Cliff {
+		return big.NewInt(0)
+	}
+
+	// total * elapsed / duration
+	vested := new(big.Int).Mul(schedule.Total.Int(), big.NewInt(now-schedule.Start))
+	return vested.Quo(vested, big.NewInt(schedule.Duration))
+}
+
+// ReleasableAmount returns the amount vested at now but not released yet
+func (schedule *VestingSchedule) ReleasableAmount(now int64) *big.Int {
+	return new(big.Int).Sub(schedule.VestedAmount(now), schedule.Released.Int())
+}
+
+// VestingStatus is the schedule with its vested & unvested amount at a point in time
+type VestingStatus struct {
+	*VestingSchedule
+	Vested     *Amount `json:"vested"`
+	Unvested   *Amount `json:"unvested"`
+	Releasable *Amount `json:"releasable"`
+}
+
+func NewVestingStatus(schedule *VestingSchedule, now int64) *VestingStatus {
+	vested := schedule.VestedAmount(now)
+	return &VestingStatus{
+		VestingSchedule: schedule,
+		Vested:          NewAmount(vested),
+		Unvested:        NewAmount(new(big.Int).Sub(schedule.Total.Int(), vested)),
+		Releasable:      NewAmount(new(big.Int).Sub(vested, schedule.Released.Int())),
+	}
+}
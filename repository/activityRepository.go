@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/erc20/model"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// RecordTransferActivity saves the activity of sender (out) & recipient (in) for the leg of transfer
+// leg is the index of the transfer in the transaction, so every activity of a transaction has its own key
+func RecordTransferActivity(stub shim.ChaincodeStubInterface, sender, recipient string, amount *big.Int, memo string, leg int) error {
+	err := RecordActivity(stub, sender, recipient, model.ActivityOut, amount, memo, 2*leg)
+	if err != nil {
+		return err
+	}
+	return RecordActivity(stub, recipient, sender, model.ActivityIn, amount, memo, 2*leg+1)
+}
+
+// RecordActivity saves the activity of address
+// key is activity/{address}/{timestamp}/{txID}/{seq}, so the activities are sorted by time
+// (timestamp is zero padded unix time in seconds)
+func RecordActivity(stub shim.ChaincodeStubInterface, address, counterparty, direction string, amount *big.Int, memo string, seq int) error {
+	txTime, err := util.GetTxTime(stub)
+	if err != nil {
+		return err
+	}
+
+	activityKey, err := stub.CreateCompositeKey(activityCompositeKey,
+		[]string{address, fmt.Sprintf("%020d", txTime), stub.GetTxID(), strconv.Itoa(seq)})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, activityCompositeKey, err.Error())
+	}
+
+	activity := model.Activity{
+		TxID:         stub.GetTxID(),
+		Timestamp:    time.Unix(txTime, 0).UTC().Format(time.RFC3339),
+		Direction:    direction,
+		Counterparty: counterparty,
+		Amount:       amount.String(),
+		Memo:         memo,
+	}
+	activityBytes, err := json.Marshal(activity)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, "activity", err.Error())
+	}
+
+	err = stub.PutState(activityKey, activityBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, activityKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetActivities returns one page of the activities of address (oldest first)
+func GetActivities(stub shim.ChaincodeStubInterface, address string, pageSize int32, bookmark string) (*model.ActivityPage, error) {
+	activityIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(activityCompositeKey, []string{address}, pageSize, bookmark)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, activityCompositeKey, err.Error())
+	}
+	if activityIterator == nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, activityCompositeKey, "pagination is not supported")
+	}
+	defer activityIterator.Close()
+
+	activities := []model.Activity{}
+	for activityIterator.HasNext() {
+		activityKV, err := activityIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, activityCompositeKey, err.Error())
+		}
+
+		activity := model.Activity{}
+		err = json.Unmarshal(activityKV.GetValue(), &activity)
+		if err != nil {
+			return nil, model.NewCustomError(model.UnMarshalErrorType, "activity", err.Error())
+		}
+		activities = append(activities, activity)
+	}
+
+	nextBookmark := ""
+	if metadata != nil && len(activities) == int(pageSize) {
+		nextBookmark = metadata.GetBookmark()
+	}
+
+	return &model.ActivityPage{Activities: activities, Bookmark: nextBookmark}, nil
+}
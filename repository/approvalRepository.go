@@ -1,58 +1,109 @@
 package repository
 
 import (
-	"strconv"
+	"encoding/json"
+	"math/big"
 
 	"github.com/erc20/model"
+	"github.com/erc20/util"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
-const approvalCompositeKey = "approval"
+// SaveAllowance saves allowance record of spender over owner tokens
+// expiresAt is unix time (seconds) when the allowance lapses, 0 means it never expires
+func SaveAllowance(stub shim.ChaincodeStubInterface, owner, spender string, allowance *big.Int, expiresAt int64) error {
+	// create composite key for allowance - approval/{owner}/{spender}
+	ownerSpenderKey, err := approvalKey(stub, owner, spender)
+	if err != nil {
+		return err
+	}
+
+	// make allowance record
+	record := model.NewAllowanceRecord(owner, spender, allowance, expiresAt)
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, "allowanceRecord", err.Error())
+	}
 
-func SaveAllowance(stub shim.ChaincodeStubInterface, owner, spender, allowance string) error {
+	// save allowance record
+	err = stub.PutState(ownerSpenderKey, recordBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, ownerSpenderKey, err.Error())
+	}
+
+	return nil
+}
+
+func DeleteAllowance(stub shim.ChaincodeStubInterface, owner, spender string) error {
 	// create composite key for allowance - approval/{owner}/{spender}
-	approvalKey, err := stub.CreateCompositeKey(approvalCompositeKey, []string{owner, spender})
+	ownerSpenderKey, err := approvalKey(stub, owner, spender)
 	if err != nil {
-		return model.NewCustomError(model.CreateCompositeKeyErrorType, approvalCompositeKey, err.Error())
+		return err
 	}
 
-	// save allowance amount
-	err = stub.PutState(approvalKey, []byte(allowance))
+	// delete allowance
+	err = stub.DelState(ownerSpenderKey)
 	if err != nil {
-		return model.NewCustomError(model.PutStateErrorType, approvalKey, err.Error())
+		return model.NewCustomError(model.DelStateErrorType, ownerSpenderKey, err.Error())
 	}
 
 	return nil
 }
 
-func GetAllowanceBytes(stub shim.ChaincodeStubInterface, owner, spender string, isZero bool) ([]byte, error) {
+// GetAllowanceRecord returns allowance record of spender over owner tokens
+// expired allowance is returned as zero allowance
+func GetAllowanceRecord(stub shim.ChaincodeStubInterface, owner, spender string) (*model.AllowanceRecord, error) {
 	// create composite key
-	approvalKey, err := stub.CreateCompositeKey(approvalCompositeKey, []string{owner, spender})
+	ownerSpenderKey, err := approvalKey(stub, owner, spender)
 	if err != nil {
-		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, approvalCompositeKey, err.Error())
+		return nil, err
 	}
 
-	allowanceBytes, err := stub.GetState(approvalKey)
+	recordBytes, err := stub.GetState(ownerSpenderKey)
 	if err != nil {
-		return nil, model.NewCustomError(model.GetStateErrorType, approvalKey, err.Error())
+		return nil, model.NewCustomError(model.GetStateErrorType, ownerSpenderKey, err.Error())
 	}
 
-	if isZero && allowanceBytes == nil {
-		allowanceBytes = []byte("0")
+	record, err := parseAllowanceRecord(owner, spender, recordBytes)
+	if err != nil {
+		return nil, err
 	}
 
-	return allowanceBytes, nil
+	// apply expiry with transaction time
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return nil, err
+	}
+	applyExpiry(record, now)
+
+	return record, nil
 }
 
-func GetApprovalList(stub shim.ChaincodeStubInterface, owner string) ([]model.Approval, error) {
+// GetAllowance returns the allowance of spender over owner tokens (zero if expired)
+func GetAllowance(stub shim.ChaincodeStubInterface, owner, spender string) (*big.Int, error) {
+	record, err := GetAllowanceRecord(stub, owner, spender)
+	if err != nil {
+		return nil, err
+	}
+
+	return record.Allowance.Int(), nil
+}
+
+func GetApprovalList(stub shim.ChaincodeStubInterface, owner string) ([]model.AllowanceRecord, error) {
 	// get all approval list (format is iterator)
 	approvalIterator, err := stub.GetStateByPartialCompositeKey(approvalCompositeKey, []string{owner})
 	if err != nil {
 		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, approvalCompositeKey, err.Error())
 	}
 
+	// get transaction time for expiry
+	now, err := util.GetTxTime(stub)
+	if err != nil {
+		return nil, err
+	}
+
 	// make slice for return value
-	approvalSlice := []model.Approval{}
+	approvalSlice := []model.AllowanceRecord{}
 
 	// iterator
 	defer approvalIterator.Close()
@@ -67,18 +118,49 @@ func GetApprovalList(stub shim.ChaincodeStubInterface, owner string) ([]model.Ap
 			}
 			spenderAddress := addresses[1]
 
-			// get amount
-			amountBytes := approvalKV.GetValue()
-			amountInt, err := strconv.Atoi(string(amountBytes))
+			// get allowance record
+			record, err := parseAllowanceRecord(owner, spenderAddress, approvalKV.GetValue())
 			if err != nil {
-				return nil, model.NewCustomError(model.ConvertErrorType, string(amountBytes), err.Error())
+				return nil, err
 			}
+			applyExpiry(record, now)
 
 			// add approval result
-			approval := model.Approval{Owner: owner, Spender: spenderAddress, Allowance: amountInt}
-			approvalSlice = append(approvalSlice, approval)
+			approvalSlice = append(approvalSlice, *record)
 		}
 	}
 
 	return approvalSlice, nil
 }
+
+// parseAllowanceRecord converts the value of approval key to allowance record
+func parseAllowanceRecord(owner, spender string, recordBytes []byte) (*model.AllowanceRecord, error) {
+	// no allowance
+	if recordBytes == nil {
+		return model.NewAllowanceRecord(owner, spender, big.NewInt(0), 0), nil
+	}
+
+	// allowance saved without expiry is amount only
+	if amount, err := util.ConvertToAmount("allowance", string(recordBytes)); err == nil {
+		return model.NewAllowanceRecord(owner, spender, amount, 0), nil
+	}
+
+	record := model.AllowanceRecord{}
+	err := json.Unmarshal(recordBytes, &record)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, "allowanceRecord", err.Error())
+	}
+	if record.Allowance == nil {
+		record.Allowance = model.NewAmount(big.NewInt(0))
+	}
+
+	return &record, nil
+}
+
+// applyExpiry makes the allowance of expired record zero
+func applyExpiry(record *model.AllowanceRecord, now int64) {
+	if record.IsExpired(now) {
+		record.Allowance = model.NewAmount(big.NewInt(0))
+		record.Expired = true
+	}
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"math/big"
+
+	"github.com/erc20/model"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// DeltaBalanceMode is the value of balance mode key of delta mode
+const DeltaBalanceMode = "delta"
+
+// IsDeltaBalanceMode returns true if the credits of owner are saved as delta keys
+func IsDeltaBalanceMode(stub shim.ChaincodeStubInterface, owner string) (bool, error) {
+	modeKey, err := stub.CreateCompositeKey(balanceModeCompositeKey, []string{owner})
+	if err != nil {
+		return false, model.NewCustomError(model.CreateCompositeKeyErrorType, balanceModeCompositeKey, err.Error())
+	}
+
+	modeBytes, err := stub.GetState(modeKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, balanceModeCompositeKey, err.Error())
+	}
+
+	return string(modeBytes) == DeltaBalanceMode, nil
+}
+
+// SaveBalanceMode turns delta mode of owner on or off
+// the credits are folded into the balance before delta mode is turned off
+func SaveBalanceMode(stub shim.ChaincodeStubInterface, owner string, deltaMode bool) error {
+	modeKey, err := stub.CreateCompositeKey(balanceModeCompositeKey, []string{owner})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, balanceModeCompositeKey, err.Error())
+	}
+
+	if deltaMode {
+		err = stub.PutState(modeKey, []byte(DeltaBalanceMode))
+		if err != nil {
+			return model.NewCustomError(model.PutStateErrorType, balanceModeCompositeKey, err.Error())
+		}
+		return nil
+	}
+
+	// fold the credits while delta mode is still on
+	_, err = CompactBalance(stub, owner)
+	if err != nil {
+		return err
+	}
+
+	err = stub.DelState(modeKey)
+	if err != nil {
+		return model.NewCustomError(model.DelStateErrorType, balanceModeCompositeKey, err.Error())
+	}
+	return nil
+}
+
+// CreditBalance adds amount to the balance of owner
+// in delta mode, the credit is saved as balanceDelta/{owner}/{txID} without reading the balance,
+// so concurrent credits to owner don't conflict
+// (every balance is at most the total supply, so the sum cannot overflow)
+// credits to the same owner in one transaction must be summed by the caller,
+// since world state doesn't read its own writes in a transaction
+func CreditBalance(stub shim.ChaincodeStubInterface, owner string, amount *big.Int) error {
+	deltaMode, err := IsDeltaBalanceMode(stub, owner)
+	if err != nil {
+		return err
+	}
+
+	if !deltaMode {
+		balance, err := GetBalance(stub, owner, true)
+		if err != nil {
+			return err
+		}
+		result, err := util.Add(balance, amount)
+		if err != nil {
+			return model.NewCustomError(model.ConvertErrorType, "balance", err.Error())
+		}
+		return SaveBalance(stub, owner, result)
+	}
+
+	// keep balance of snapshot (reads the balance only on the first credit after snapshot)
+	err = snapshotBalance(stub, owner)
+	if err != nil {
+		return err
+	}
+
+	// save credit as delta key
+	deltaKey, err := stub.CreateCompositeKey(balanceDeltaCompositeKey, []string{owner, stub.GetTxID()})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, balanceDeltaCompositeKey, err.Error())
+	}
+	err = stub.PutState(deltaKey, []byte(amount.String()))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, deltaKey, err.Error())
+	}
+
+	// credit makes owner a holder (blind write, so it doesn't conflict either)
+	if amount.Sign() == 0 {
+		return nil
+	}
+	return saveHolder(stub, owner, true)
+}
+
+// CompactBalance folds the credits of delta mode into the balance of owner
+// Returns the balance of owner
+func CompactBalance(stub shim.ChaincodeStubInterface, owner string) (*big.Int, error) {
+	balance, err := GetBalance(stub, owner, true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = SaveBalance(stub, owner, balance)
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// getBalanceDeltas returns the sum & keys of the credits of owner
+func getBalanceDeltas(stub shim.ChaincodeStubInterface, owner string) (*big.Int, []string, error) {
+	deltaIterator, err := stub.GetStateByPartialCompositeKey(balanceDeltaCompositeKey, []string{owner})
+	if err != nil {
+		return nil, nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, balanceDeltaCompositeKey, err.Error())
+	}
+	defer deltaIterator.Close()
+
+	sum := big.NewInt(0)
+	keys := []string{}
+	for deltaIterator.HasNext() {
+		deltaKV, err := deltaIterator.Next()
+		if err != nil {
+			return nil, nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, balanceDeltaCompositeKey, err.Error())
+		}
+
+		amount, err := util.ConvertToAmount("balanceDelta", string(deltaKV.GetValue()))
+		if err != nil {
+			return nil, nil, err
+		}
+		sum.Add(sum, amount)
+		keys = append(keys, deltaKV.GetKey())
+	}
+
+	return sum, keys, nil
+}
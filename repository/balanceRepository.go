@@ -2,22 +2,34 @@ package repository
 
 import (
 	"encoding/json"
-	"strconv"
+	"math/big"
 
 	"github.com/erc20/model"
+	"github.com/erc20/util"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
-func SaveERC20Metadata(stub shim.ChaincodeStubInterface, tokenName, symbol, owner string, amount uint64) error {
+// SaveERC20Metadata saves token metadata
+// the total supply before the first change after the current snapshot is kept for the snapshot
+func SaveERC20Metadata(stub shim.ChaincodeStubInterface, erc20 *model.ERC20Metadata) error {
+	// keep total supply of snapshot
+	err := snapshotTotalSupply(stub, *erc20.GetName())
+	if err != nil {
+		return err
+	}
+
 	// make metadata
-	erc20 := model.NewERC20MetaData(tokenName, symbol, owner, amount)
 	erc20Bytes, err := json.Marshal(erc20)
 	if err != nil {
 		return model.NewCustomError(model.MarshalErrorType, "erc20", err.Error())
 	}
 
-	// save token meta data
-	err = stub.PutState(tokenName, erc20Bytes)
+	// save token meta data - erc20Metadata/{tokenName}
+	erc20Key, err := metadataKey(stub, *erc20.GetName())
+	if err != nil {
+		return err
+	}
+	err = stub.PutState(erc20Key, erc20Bytes)
 	if err != nil {
 		return model.NewCustomError(model.PutStateErrorType, "erc20Metadata", err.Error())
 	}
@@ -27,8 +39,12 @@ func SaveERC20Metadata(stub shim.ChaincodeStubInterface, tokenName, symbol, owne
 
 func GetERC20Metadata(stub shim.ChaincodeStubInterface, tokenName string) (*model.ERC20Metadata, error) {
 	// Get ERC20 Metadata
+	erc20Key, err := metadataKey(stub, tokenName)
+	if err != nil {
+		return nil, err
+	}
 	erc20 := model.ERC20Metadata{}
-	erc20Bytes, err := stub.GetState(tokenName)
+	erc20Bytes, err := stub.GetState(erc20Key)
 	if err != nil {
 		return nil, model.NewCustomError(model.GetStateErrorType, "balance", err.Error())
 	}
@@ -39,54 +55,109 @@ func GetERC20Metadata(stub shim.ChaincodeStubInterface, tokenName string) (*mode
 	return &erc20, nil
 }
 
-func GetERC20TotalSupply(stub shim.ChaincodeStubInterface, tokenName string) (*uint64, error) {
-	// Get ERC20 Metadata
-	erc20 := model.ERC20Metadata{}
-	erc20Bytes, err := stub.GetState(tokenName)
+// HasERC20Metadata returns true if metadata of any token is saved (the chaincode is already initialized)
+func HasERC20Metadata(stub shim.ChaincodeStubInterface) (bool, error) {
+	iterator, err := stub.GetStateByPartialCompositeKey(metadataCompositeKey, []string{})
 	if err != nil {
-		return nil, model.NewCustomError(model.GetStateErrorType, "balance", err.Error())
+		return false, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, metadataCompositeKey, err.Error())
 	}
-	err = json.Unmarshal(erc20Bytes, &erc20)
+	defer iterator.Close()
+
+	return iterator.HasNext(), nil
+}
+
+func GetERC20TotalSupply(stub shim.ChaincodeStubInterface, tokenName string) (*big.Int, error) {
+	// Get ERC20 Metadata
+	erc20, err := GetERC20Metadata(stub, tokenName)
 	if err != nil {
-		return nil, model.NewCustomError(model.UnMarshalErrorType, "erc20Metadata", err.Error())
+		return nil, err
 	}
 	return erc20.GetTotalSupply(), nil
 }
 
-func SaveBalance(stub shim.ChaincodeStubInterface, owner, balance string) error {
-	err := stub.PutState(owner, []byte(balance))
+// SaveBalance saves the balance of owner & keeps the holder index current
+// the credits of delta mode are folded into the saved balance
+// the balance before the first change after the current snapshot is kept for the snapshot
+func SaveBalance(stub shim.ChaincodeStubInterface, owner string, balance *big.Int) error {
+	// keep balance of snapshot
+	err := snapshotBalance(stub, owner)
+	if err != nil {
+		return err
+	}
+
+	// delete the credits of delta mode
+	deltaMode, err := IsDeltaBalanceMode(stub, owner)
+	if err != nil {
+		return err
+	}
+	if deltaMode {
+		_, deltaKeys, err := getBalanceDeltas(stub, owner)
+		if err != nil {
+			return err
+		}
+		for _, deltaKey := range deltaKeys {
+			err = stub.DelState(deltaKey)
+			if err != nil {
+				return model.NewCustomError(model.DelStateErrorType, deltaKey, err.Error())
+			}
+		}
+	}
+
+	// save balance - balance/{owner}
+	ownerKey, err := balanceKey(stub, owner)
+	if err != nil {
+		return err
+	}
+	err = stub.PutState(ownerKey, []byte(balance.String()))
 	if err != nil {
 		return model.NewCustomError(model.PutStateErrorType, "balance", err.Error())
 	}
 
-	return nil
+	// add owner to holder index when balance is nonzero, remove when zero
+	return saveHolder(stub, owner, balance.Sign() != 0)
 }
 
 func GetBalanceBytes(stub shim.ChaincodeStubInterface, owner string, isZeror bool) ([]byte, error) {
-	amountBytes, err := stub.GetState(owner)
+	balance, err := GetBalance(stub, owner, true)
 	if err != nil {
-		return nil, model.NewCustomError(model.GetStateErrorType, owner, err.Error())
+		return nil, err
 	}
-	if amountBytes == nil {
-		amountBytes = []byte("0")
-	}
-	return amountBytes, nil
+	return []byte(balance.String()), nil
 }
 
-func GetBalance(stub shim.ChaincodeStubInterface, owner string, isZero bool) (*int, error) {
-	amountBytes, err := stub.GetState(owner)
+// GetBalance returns the balance of owner
+// the credits of delta mode are added to the saved balance
+func GetBalance(stub shim.ChaincodeStubInterface, owner string, isZero bool) (*big.Int, error) {
+	ownerKey, err := balanceKey(stub, owner)
+	if err != nil {
+		return nil, err
+	}
+	amountBytes, err := stub.GetState(ownerKey)
 	if err != nil {
 		return nil, model.NewCustomError(model.GetStateErrorType, "balance", err.Error())
 	}
 
-	if isZero && amountBytes == nil {
+	// add the credits of delta mode
+	deltaMode, err := IsDeltaBalanceMode(stub, owner)
+	if err != nil {
+		return nil, err
+	}
+	if (isZero || deltaMode) && amountBytes == nil {
 		amountBytes = []byte("0")
 	}
 
-	amount, err := strconv.Atoi(string(amountBytes))
+	balance, err := util.ConvertToAmount("balance", string(amountBytes))
 	if err != nil {
-		return nil, model.NewCustomError(model.ConvertErrorType, "amount", err.Error())
+		return nil, err
+	}
+
+	if deltaMode {
+		deltaSum, _, err := getBalanceDeltas(stub, owner)
+		if err != nil {
+			return nil, err
+		}
+		balance.Add(balance, deltaSum)
 	}
 
-	return &amount, nil
+	return balance, nil
 }
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func SaveFrozen(stub shim.ChaincodeStubInterface, address string, frozen bool) error {
+	return saveRestriction(stub, frozenCompositeKey, address, frozen)
+}
+
+func IsFrozen(stub shim.ChaincodeStubInterface, address string) (bool, error) {
+	return isRestricted(stub, frozenCompositeKey, address)
+}
+
+func SaveBlacklisted(stub shim.ChaincodeStubInterface, address string, blacklisted bool) error {
+	return saveRestriction(stub, blacklistCompositeKey, address, blacklisted)
+}
+
+func IsBlacklisted(stub shim.ChaincodeStubInterface, address string) (bool, error) {
+	return isRestricted(stub, blacklistCompositeKey, address)
+}
+
+// saveRestriction saves restriction(frozen or blacklist) of address - {restriction}/{address}
+// the key is deleted when the restriction is lifted
+func saveRestriction(stub shim.ChaincodeStubInterface, restriction, address string, restricted bool) error {
+	key, err := stub.CreateCompositeKey(restriction, []string{address})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, restriction, err.Error())
+	}
+
+	if restricted {
+		err = stub.PutState(key, existsValue)
+		if err != nil {
+			return model.NewCustomError(model.PutStateErrorType, restriction, err.Error())
+		}
+		return nil
+	}
+
+	err = stub.DelState(key)
+	if err != nil {
+		return model.NewCustomError(model.DelStateErrorType, restriction, err.Error())
+	}
+	return nil
+}
+
+func isRestricted(stub shim.ChaincodeStubInterface, restriction, address string) (bool, error) {
+	key, err := stub.CreateCompositeKey(restriction, []string{address})
+	if err != nil {
+		return false, model.NewCustomError(model.CreateCompositeKeyErrorType, restriction, err.Error())
+	}
+
+	restrictedBytes, err := stub.GetState(key)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, restriction, err.Error())
+	}
+
+	return restrictedBytes != nil, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// EnvelopeEventKey is the name of the chaincode event which carries every event of the transaction
+const EnvelopeEventKey = "erc20Events"
+
+// EventBuffer is the chaincode stub which collects every event raised during an invocation
+// fabric keeps only the last SetEvent of a transaction,
+// so the events are emitted together as one envelope event by Flush
+type EventBuffer struct {
+	shim.ChaincodeStubInterface
+	events []model.Event
+}
+
+func NewEventBuffer(stub shim.ChaincodeStubInterface) *EventBuffer {
+	return &EventBuffer{
+		ChaincodeStubInterface: stub,
+		events:                 []model.Event{},
+	}
+}
+
+// SetEvent adds the event to the buffer
+func (buffer *EventBuffer) SetEvent(name string, payload []byte) error {
+	if len(name) == 0 {
+		return model.NewCustomError(model.SetEventErrorType, "event", "event name cannot be empty")
+	}
+
+	buffer.events = append(buffer.events, model.Event{Type: name, Payload: payload})
+	return nil
+}
+
+// Flush emits every buffered event as one envelope event
+// nothing is emitted when there is no event
+func (buffer *EventBuffer) Flush() error {
+	if len(buffer.events) == 0 {
+		return nil
+	}
+
+	envelope := model.NewEventEnvelope(buffer.GetTxID(), buffer.events)
+	envelopeBytes, err := json.Marshal(envelope)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, EnvelopeEventKey, err.Error())
+	}
+
+	err = buffer.ChaincodeStubInterface.SetEvent(EnvelopeEventKey, envelopeBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, EnvelopeEventKey, err.Error())
+	}
+
+	buffer.events = []model.Event{}
+	return nil
+}
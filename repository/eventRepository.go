@@ -2,17 +2,34 @@ package repository
 
 import (
 	"encoding/json"
+	"math/big"
 
 	"github.com/erc20/model"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 )
 
 const (
-	TransferEventKey = "transferEvent"
-	ApprovalEventKey = "approvalEvent"
+	TransferEventKey                = "transferEvent"
+	ApprovalEventKey                = "approvalEvent"
+	RoleGrantedEventKey             = "roleGrantedEvent"
+	RoleRevokedEventKey             = "roleRevokedEvent"
+	PausedEventKey                  = "pausedEvent"
+	UnpausedEventKey                = "unpausedEvent"
+	FrozenEventKey                  = "frozenEvent"
+	UnfrozenEventKey                = "unfrozenEvent"
+	BlacklistedEventKey             = "blacklistedEvent"
+	UnblacklistedEventKey           = "unblacklistedEvent"
+	FrozenWipedEventKey             = "frozenWipedEvent"
+	SnapshotEventKey                = "snapshotEvent"
+	HTLCLockedEventKey              = "htlcLockedEvent"
+	HTLCClaimedEventKey             = "htlcClaimedEvent"
+	HTLCRefundedEventKey            = "htlcRefundedEvent"
+	ExternalCallEventKey            = "externalCallEvent"
+	TrustedChaincodeAddedEventKey   = "trustedChaincodeAddedEvent"
+	TrustedChaincodeRemovedEventKey = "trustedChaincodeRemovedEvent"
 )
 
-func EmitTransferEvent(stub shim.ChaincodeStubInterface, sender, spender string, amount int) error {
+func EmitTransferEvent(stub shim.ChaincodeStubInterface, sender, spender string, amount *big.Int) error {
 	transferEvent := model.NewTransferEvent(sender, spender, amount)
 	transferEventBytes, err := json.Marshal(transferEvent)
 	if err != nil {
@@ -26,7 +43,7 @@ func EmitTransferEvent(stub shim.ChaincodeStubInterface, sender, spender string,
 	return nil
 }
 
-func EmitApprovalEvent(stub shim.ChaincodeStubInterface, owner, spender string, allowance int) error {
+func EmitApprovalEvent(stub shim.ChaincodeStubInterface, owner, spender string, allowance *big.Int) error {
 	approvalEvent := model.NewApproval(owner, spender, allowance)
 	approvalBytes, err := json.Marshal(approvalEvent)
 	if err != nil {
@@ -40,3 +57,114 @@ func EmitApprovalEvent(stub shim.ChaincodeStubInterface, owner, spender string,
 
 	return nil
 }
+
+// EmitRoleEvent emits RoleGranted or RoleRevoked event (eventKey)
+func EmitRoleEvent(stub shim.ChaincodeStubInterface, eventKey, role, account, sender string) error {
+	roleEvent := model.NewRoleEvent(role, account, sender)
+	roleEventBytes, err := json.Marshal(roleEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, eventKey, err.Error())
+	}
+
+	err = stub.SetEvent(eventKey, roleEventBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, eventKey, err.Error())
+	}
+
+	return nil
+}
+
+// EmitPauseEvent emits Paused or Unpaused event (eventKey)
+func EmitPauseEvent(stub shim.ChaincodeStubInterface, eventKey, account string) error {
+	pauseEvent := model.NewPauseEvent(account)
+	pauseEventBytes, err := json.Marshal(pauseEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, eventKey, err.Error())
+	}
+
+	err = stub.SetEvent(eventKey, pauseEventBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, eventKey, err.Error())
+	}
+
+	return nil
+}
+
+// EmitComplianceEvent emits Frozen, Unfrozen, Blacklisted, Unblacklisted or FrozenWiped event (eventKey)
+// amount is nil except FrozenWiped event
+func EmitComplianceEvent(stub shim.ChaincodeStubInterface, eventKey, account, sender string, amount *big.Int) error {
+	complianceEvent := model.NewComplianceEvent(account, sender, amount)
+	complianceEventBytes, err := json.Marshal(complianceEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, eventKey, err.Error())
+	}
+
+	err = stub.SetEvent(eventKey, complianceEventBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, eventKey, err.Error())
+	}
+
+	return nil
+}
+
+func EmitSnapshotEvent(stub shim.ChaincodeStubInterface, id uint64, sender string) error {
+	snapshotEvent := model.NewSnapshotEvent(id, sender)
+	snapshotEventBytes, err := json.Marshal(snapshotEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, SnapshotEventKey, err.Error())
+	}
+
+	err = stub.SetEvent(SnapshotEventKey, snapshotEventBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, SnapshotEventKey, err.Error())
+	}
+
+	return nil
+}
+
+// EmitHTLCEvent emits HTLCLocked, HTLCClaimed or HTLCRefunded event (eventKey)
+func EmitHTLCEvent(stub shim.ChaincodeStubInterface, eventKey string, htlc *model.HTLC) error {
+	htlcBytes, err := json.Marshal(htlc)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, eventKey, err.Error())
+	}
+
+	err = stub.SetEvent(eventKey, htlcBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, eventKey, err.Error())
+	}
+
+	return nil
+}
+
+// EmitExternalCallEvent emits ExternalCall event of successful call
+func EmitExternalCallEvent(stub shim.ChaincodeStubInterface, caller, chaincode, function string) error {
+	externalCallEvent := model.NewExternalCallEvent(caller, chaincode, function)
+	externalCallEventBytes, err := json.Marshal(externalCallEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, ExternalCallEventKey, err.Error())
+	}
+
+	err = stub.SetEvent(ExternalCallEventKey, externalCallEventBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, ExternalCallEventKey, err.Error())
+	}
+
+	return nil
+}
+
+// EmitTrustedChaincodeEvent emits TrustedChaincodeAdded or TrustedChaincodeRemoved event (eventKey)
+func EmitTrustedChaincodeEvent(stub shim.ChaincodeStubInterface, eventKey, name, sender string) error {
+	trustedChaincodeEvent := model.NewTrustedChaincodeEvent(name, sender)
+	trustedChaincodeEventBytes, err := json.Marshal(trustedChaincodeEvent)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, eventKey, err.Error())
+	}
+
+	err = stub.SetEvent(eventKey, trustedChaincodeEventBytes)
+	if err != nil {
+		return model.NewCustomError(model.SetEventErrorType, eventKey, err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+)
+
+// GetBalanceAt returns the balance of owner at time at
+// balance is zero if balance key was never written or was deleted at time at
+// Returns error if owner was in delta mode at time at, since its uncompacted credits are not in the balance key
+func GetBalanceAt(stub shim.ChaincodeStubInterface, owner string, at time.Time) (*model.HistoricalValue, error) {
+	// check balance mode at time at
+	modeKey, err := stub.CreateCompositeKey(balanceModeCompositeKey, []string{owner})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, balanceModeCompositeKey, err.Error())
+	}
+	modeModification, err := getModificationAt(stub, modeKey, at)
+	if err != nil {
+		return nil, err
+	}
+	if modeModification != nil && !modeModification.GetIsDelete() && string(modeModification.GetValue()) == DeltaBalanceMode {
+		return nil, errors.New(owner + " was in delta mode at " + at.UTC().Format(time.RFC3339) + ", use balanceOfAt with snapshot id")
+	}
+
+	ownerKey, err := balanceKey(stub, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	modification, err := getModificationAt(stub, ownerKey, at)
+	if err != nil {
+		return nil, err
+	}
+	if modification == nil || modification.GetIsDelete() {
+		return newHistoricalValue("0", modification), nil
+	}
+
+	return newHistoricalValue(string(modification.GetValue()), modification), nil
+}
+
+// GetTotalSupplyAt returns the total supply of token at time at
+// Returns error if the token didn't exist at time at
+func GetTotalSupplyAt(stub shim.ChaincodeStubInterface, tokenName string, at time.Time) (*model.HistoricalValue, error) {
+	erc20Key, err := metadataKey(stub, tokenName)
+	if err != nil {
+		return nil, err
+	}
+
+	modification, err := getModificationAt(stub, erc20Key, at)
+	if err != nil {
+		return nil, err
+	}
+	if modification == nil || modification.GetIsDelete() {
+		return nil, errors.New(tokenName + " didn't exist at " + at.UTC().Format(time.RFC3339))
+	}
+
+	erc20 := model.ERC20Metadata{}
+	err = json.Unmarshal(modification.GetValue(), &erc20)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, "erc20Metadata", err.Error())
+	}
+
+	return newHistoricalValue(erc20.GetTotalSupply().String(), modification), nil
+}
+
+// getModificationAt returns the last modification of key at or before time at
+// Returns nil if key was not modified before time at
+func getModificationAt(stub shim.ChaincodeStubInterface, key string, at time.Time) (*queryresult.KeyModification, error) {
+	iterator, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetHistoryForKeyErrorType, key, err.Error())
+	}
+	defer iterator.Close()
+
+	var last *queryresult.KeyModification
+	var lastTime time.Time
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetHistoryForKeyErrorType, key, err.Error())
+		}
+
+		// skip the modifications after time at
+		ts := modification.GetTimestamp()
+		modifiedAt := time.Unix(ts.GetSeconds(), int64(ts.GetNanos()))
+		if modifiedAt.After(at) {
+			continue
+		}
+
+		// history is not guaranteed to be sorted
+		if last == nil || !modifiedAt.Before(lastTime) {
+			last, lastTime = modification, modifiedAt
+		}
+	}
+
+	return last, nil
+}
+
+func newHistoricalValue(value string, modification *queryresult.KeyModification) *model.HistoricalValue {
+	if modification == nil {
+		return &model.HistoricalValue{Value: value}
+	}
+
+	ts := modification.GetTimestamp()
+	return &model.HistoricalValue{
+		Value:     value,
+		TxID:      modification.GetTxId(),
+		Timestamp: time.Unix(ts.GetSeconds(), int64(ts.GetNanos())).UTC().Format(time.RFC3339Nano),
+	}
+}
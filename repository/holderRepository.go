@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// saveHolder adds address to the holder index or removes it
+func saveHolder(stub shim.ChaincodeStubInterface, address string, isHolder bool) error {
+	// create composite key for holder - holder/{address}
+	holderKey, err := stub.CreateCompositeKey(holderCompositeKey, []string{address})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, holderCompositeKey, err.Error())
+	}
+
+	if isHolder {
+		err = stub.PutState(holderKey, existsValue)
+		if err != nil {
+			return model.NewCustomError(model.PutStateErrorType, holderKey, err.Error())
+		}
+		return nil
+	}
+
+	err = stub.DelState(holderKey)
+	if err != nil {
+		return model.NewCustomError(model.DelStateErrorType, holderKey, err.Error())
+	}
+	return nil
+}
+
+// GetHolders returns one page of the addresses which have nonzero balance
+func GetHolders(stub shim.ChaincodeStubInterface, pageSize int32, bookmark string) (*model.HolderPage, error) {
+	holderIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(holderCompositeKey, []string{}, pageSize, bookmark)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, holderCompositeKey, err.Error())
+	}
+	if holderIterator == nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, holderCompositeKey, "pagination is not supported")
+	}
+	defer holderIterator.Close()
+
+	holders := []string{}
+	for holderIterator.HasNext() {
+		holderKV, err := holderIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, holderCompositeKey, err.Error())
+		}
+
+		// get holder address
+		_, attributes, err := stub.SplitCompositeKey(holderKV.GetKey())
+		if err != nil {
+			return nil, model.NewCustomError(model.SpliteCompositeKeyErrorType, holderKV.GetKey(), err.Error())
+		}
+		holders = append(holders, attributes[0])
+	}
+
+	nextBookmark := ""
+	if metadata != nil && len(holders) == int(pageSize) {
+		nextBookmark = metadata.GetBookmark()
+	}
+
+	return model.NewHolderPage(holders, nextBookmark), nil
+}
+
+// GetHolderCount returns the number of addresses which have nonzero balance
+// the count is not saved as a key, so balance updates don't conflict on it
+func GetHolderCount(stub shim.ChaincodeStubInterface) (int, error) {
+	holderIterator, err := stub.GetStateByPartialCompositeKey(holderCompositeKey, []string{})
+	if err != nil {
+		return 0, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, holderCompositeKey, err.Error())
+	}
+	defer holderIterator.Close()
+
+	count := 0
+	for holderIterator.HasNext() {
+		_, err := holderIterator.Next()
+		if err != nil {
+			return 0, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, holderCompositeKey, err.Error())
+		}
+		count++
+	}
+
+	return count, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// HTLCEscrow returns the escrow name of HTLC id (counterparty of its transfers)
+func HTLCEscrow(id string) string {
+	return htlcCompositeKey + "/" + id
+}
+
+// SaveHTLC saves htlc - htlc/{id}
+func SaveHTLC(stub shim.ChaincodeStubInterface, htlc *model.HTLC) error {
+	htlcKey, err := stub.CreateCompositeKey(htlcCompositeKey, []string{htlc.ID})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, htlcCompositeKey, err.Error())
+	}
+
+	htlcBytes, err := json.Marshal(htlc)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, "htlc", err.Error())
+	}
+
+	err = stub.PutState(htlcKey, htlcBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, htlcKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetHTLC returns htlc of id
+func GetHTLC(stub shim.ChaincodeStubInterface, id string) (*model.HTLC, error) {
+	htlcKey, err := stub.CreateCompositeKey(htlcCompositeKey, []string{id})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, htlcCompositeKey, err.Error())
+	}
+
+	htlcBytes, err := stub.GetState(htlcKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, htlcKey, err.Error())
+	}
+	if htlcBytes == nil {
+		return nil, errors.New("htlc " + id + " doesn't exist")
+	}
+
+	htlc := model.HTLC{}
+	err = json.Unmarshal(htlcBytes, &htlc)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, "htlc", err.Error())
+	}
+
+	return &htlc, nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/erc20/model"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+)
+
+// composite key prefixes of world state
+//   - balance/{address}
+//   - balanceDelta/{address}/{txID}
+//   - balanceMode/{address}
+//   - holder/{address}
+//   - activity/{address}/{timestamp}/{txID}/{seq}
+//   - snapshotId
+//   - snapshotBalance/{id}/{address}
+//   - snapshotTotalSupply/{id}/{tokenName}
+//   - vesting/{beneficiary}/{id}
+//   - lock/{recipient}/{id}
+//   - lockedAmount/{recipient}
+//   - htlc/{id}
+//   - swapOffer/{counterparty}/{taker}
+//   - trustedChaincode/{name}
+//   - erc20Metadata/{tokenName}
+//   - role/{role}/{address}
+//   - approval/{owner}/{spender}
+//   - erc20Paused
+//   - frozen/{address}
+//   - blacklist/{address}
+//   - permitKey/{address}
+//   - permitNonce/{owner}
+//   - permitDomain
+const (
+	balanceCompositeKey             = "balance"
+	balanceDeltaCompositeKey        = "balanceDelta"
+	balanceModeCompositeKey         = "balanceMode"
+	holderCompositeKey              = "holder"
+	activityCompositeKey            = "activity"
+	snapshotIDCompositeKey          = "snapshotId"
+	snapshotBalanceCompositeKey     = "snapshotBalance"
+	snapshotTotalSupplyCompositeKey = "snapshotTotalSupply"
+	vestingCompositeKey             = "vesting"
+	lockCompositeKey                = "lock"
+	lockedAmountCompositeKey        = "lockedAmount"
+	htlcCompositeKey                = "htlc"
+	swapOfferCompositeKey           = "swapOffer"
+	trustedChaincodeCompositeKey    = "trustedChaincode"
+	metadataCompositeKey            = "erc20Metadata"
+	roleCompositeKey                = "role"
+	approvalCompositeKey            = "approval"
+	pausedCompositeKey              = "erc20Paused"
+	frozenCompositeKey              = "frozen"
+	blacklistCompositeKey           = "blacklist"
+	permitKeyCompositeKey           = "permitKey"
+	permitNonceCompositeKey         = "permitNonce"
+	permitDomainCompositeKey        = "permitDomain"
+)
+
+// existsValue is the value saved under the index & flag keys (role, holder, frozen, blacklist, trustedChaincode)
+// the existence of the key is the value, so only a marker byte is saved
+var existsValue = []byte{0x01}
+
+// compositeKeyNamespace is the first character of every composite key
+const compositeKeyNamespace = "\x00"
+
+func balanceKey(stub shim.ChaincodeStubInterface, address string) (string, error) {
+	key, err := stub.CreateCompositeKey(balanceCompositeKey, []string{address})
+	if err != nil {
+		return "", model.NewCustomError(model.CreateCompositeKeyErrorType, balanceCompositeKey, err.Error())
+	}
+	return key, nil
+}
+
+func metadataKey(stub shim.ChaincodeStubInterface, tokenName string) (string, error) {
+	key, err := stub.CreateCompositeKey(metadataCompositeKey, []string{tokenName})
+	if err != nil {
+		return "", model.NewCustomError(model.CreateCompositeKeyErrorType, metadataCompositeKey, err.Error())
+	}
+	return key, nil
+}
+
+func approvalKey(stub shim.ChaincodeStubInterface, owner, spender string) (string, error) {
+	key, err := stub.CreateCompositeKey(approvalCompositeKey, []string{owner, spender})
+	if err != nil {
+		return "", model.NewCustomError(model.CreateCompositeKeyErrorType, approvalCompositeKey, err.Error())
+	}
+	return key, nil
+}
+
+func pausedKey(stub shim.ChaincodeStubInterface) (string, error) {
+	key, err := stub.CreateCompositeKey(pausedCompositeKey, []string{})
+	if err != nil {
+		return "", model.NewCustomError(model.CreateCompositeKeyErrorType, pausedCompositeKey, err.Error())
+	}
+	return key, nil
+}
+
+// MigrateFlatKeys rewrites token metadata & balances saved under flat keys
+// (raw token name & raw address) into the composite key layout
+// one call examines at most pageSize flat keys from bookmark (first flat key if empty),
+// and result.Bookmark is the flat key to continue from (empty when every flat key is examined)
+// the other flat keys are not changed & are reported as skipped
+// migrated balance is added to the balance already saved under the composite key,
+// and migration fails if metadata is already saved under the composite key
+// (range query is used instead of pagination, since pagination is not allowed in update transactions)
+func MigrateFlatKeys(stub shim.ChaincodeStubInterface, pageSize int, bookmark string) (*model.MigrationResult, error) {
+	// get one page of flat keys from bookmark
+	page, nextBookmark, err := getFlatKeyPage(stub, pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	result := model.NewMigrationResult()
+	result.Bookmark = nextBookmark
+	for _, kv := range page {
+		key, value := kv.GetKey(), kv.GetValue()
+
+		if legacy, ok := parseFlatMetadata(key, value); ok {
+			// token metadata is saved under token name (fields are converted to the current metadata)
+			err = migrateFlatMetadata(stub, legacy)
+			if err != nil {
+				return nil, err
+			}
+			result.Metadata = append(result.Metadata, key)
+		} else if isFlatBalance(key, value) {
+			// balance is saved under address
+			amount, _ := new(big.Int).SetString(string(value), 10)
+			err = CreditBalance(stub, key, amount)
+			if err != nil {
+				return nil, err
+			}
+			result.Balances = append(result.Balances, key)
+		} else {
+			result.Skipped = append(result.Skipped, key)
+			continue
+		}
+
+		// delete flat key
+		err = stub.DelState(key)
+		if err != nil {
+			return nil, model.NewCustomError(model.DelStateErrorType, key, err.Error())
+		}
+	}
+
+	return result, nil
+}
+
+// getFlatKeyPage returns at most pageSize flat keys from bookmark & the flat key after them (empty if none)
+func getFlatKeyPage(stub shim.ChaincodeStubInterface, pageSize int, bookmark string) ([]*queryresult.KV, string, error) {
+	iterator, err := stub.GetStateByRange(bookmark, "")
+	if err != nil {
+		return nil, "", model.NewCustomError(model.GetStateByRangeErrorType, "flat keys", err.Error())
+	}
+	defer iterator.Close()
+
+	page := []*queryresult.KV{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, "", model.NewCustomError(model.GetStateByRangeErrorType, "flat keys", err.Error())
+		}
+
+		// composite keys are already migrated
+		if strings.HasPrefix(kv.GetKey(), compositeKeyNamespace) {
+			continue
+		}
+		if len(page) == pageSize {
+			return page, kv.GetKey(), nil
+		}
+		page = append(page, kv)
+	}
+
+	return page, "", nil
+}
+
+// migrateFlatMetadata saves legacy metadata under the composite key
+// Returns error if metadata of the token is already saved
+func migrateFlatMetadata(stub shim.ChaincodeStubInterface, legacy *legacyERC20Metadata) error {
+	erc20Key, err := metadataKey(stub, legacy.Name)
+	if err != nil {
+		return err
+	}
+	erc20Bytes, err := stub.GetState(erc20Key)
+	if err != nil {
+		return model.NewCustomError(model.GetStateErrorType, erc20Key, err.Error())
+	}
+	if erc20Bytes != nil {
+		return errors.New("metadata of " + legacy.Name + " already exists")
+	}
+
+	erc20 := model.NewERC20MetaData(legacy.Name, legacy.Symbol, legacyDecimals, legacy.Owner, new(big.Int).SetUint64(legacy.TotalSupply))
+	erc20Bytes, err = json.Marshal(erc20)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, "erc20Metadata", err.Error())
+	}
+	err = stub.PutState(erc20Key, erc20Bytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, erc20Key, err.Error())
+	}
+
+	return nil
+}
+
+// HasFlatMetadata returns true if metadata of tokenName is saved under flat key (not migrated yet)
+func HasFlatMetadata(stub shim.ChaincodeStubInterface, tokenName string) (bool, error) {
+	value, err := stub.GetState(tokenName)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, tokenName, err.Error())
+	}
+	_, ok := parseFlatMetadata(tokenName, value)
+	return ok, nil
+}
+
+// legacyDecimals is the decimals of migrated token (flat metadata had no decimals, amounts are whole tokens)
+const legacyDecimals = 0
+
+// legacyERC20Metadata is token metadata saved under flat key
+type legacyERC20Metadata struct {
+	Name        string `json:"name"`
+	Symbol      string `json:"symbol"`
+	Owner       string `json:"owner"`
+	TotalSupply uint64 `json:"totalSupply"`
+}
+
+// parseFlatMetadata returns token metadata if value has exactly the fields of flat metadata of token name (key)
+func parseFlatMetadata(key string, value []byte) (*legacyERC20Metadata, bool) {
+	legacy := legacyERC20Metadata{}
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&legacy); err != nil {
+		return nil, false
+	}
+	return &legacy, legacy.Name == key && len(legacy.Symbol) != 0
+}
+
+// isFlatBalance returns true if key is an address & value is amount of token
+func isFlatBalance(key string, value []byte) bool {
+	if !isAddress(key) {
+		return false
+	}
+	_, err := util.ConvertToAmount("balance", string(value))
+	return err == nil
+}
+
+// isAddress returns true if key has the shape of address (40 lowercase hex characters)
+func isAddress(key string) bool {
+	if len(key) != 40 || key != strings.ToLower(key) {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	sc "github.com/hyperledger/fabric/protos/peer"
+)
+
+// GetStatePage returns one page of world state between startKey & endKey
+// composite keys are not included (use GetCompositeStatePage)
+func GetStatePage(stub shim.ChaincodeStubInterface, startKey, endKey string, pageSize int32, bookmark string) (*model.StatePage, error) {
+	iterator, metadata, err := stub.GetStateByRangeWithPagination(startKey, endKey, pageSize, bookmark)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateByRangeErrorType, "state", err.Error())
+	}
+	if iterator == nil {
+		return nil, model.NewCustomError(model.GetStateByRangeErrorType, "state", "pagination is not supported")
+	}
+	defer iterator.Close()
+
+	return readStatePage(iterator, metadata, pageSize, model.GetStateByRangeErrorType)
+}
+
+// GetCompositeStatePage returns one page of world state under the composite key objectType/{attributes...}
+func GetCompositeStatePage(stub shim.ChaincodeStubInterface, objectType string, attributes []string, pageSize int32, bookmark string) (*model.StatePage, error) {
+	iterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(objectType, attributes, pageSize, bookmark)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, objectType, err.Error())
+	}
+	if iterator == nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, objectType, "pagination is not supported")
+	}
+	defer iterator.Close()
+
+	return readStatePage(iterator, metadata, pageSize, model.GetStatePartialCompositeKeyErrorType)
+}
+
+// readStatePage reads every key & value of iterator
+func readStatePage(iterator shim.StateQueryIteratorInterface, metadata *sc.QueryResponseMetadata, pageSize int32, errorType string) (*model.StatePage, error) {
+	states := []model.StateKV{}
+	for iterator.HasNext() {
+		kv, err := iterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(errorType, "state", err.Error())
+		}
+		states = append(states, model.StateKV{Key: kv.GetKey(), Value: string(kv.GetValue())})
+	}
+
+	bookmark := ""
+	if metadata != nil && len(states) == int(pageSize) {
+		bookmark = metadata.GetBookmark()
+	}
+
+	return &model.StatePage{States: states, Bookmark: bookmark}, nil
+}
+
+// GetKeyHistory returns every modification of key (oldest first)
+func GetKeyHistory(stub shim.ChaincodeStubInterface, key string) ([]model.KeyModification, error) {
+	iterator, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetHistoryForKeyErrorType, key, err.Error())
+	}
+	defer iterator.Close()
+
+	history := []model.KeyModification{}
+	for iterator.HasNext() {
+		modification, err := iterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetHistoryForKeyErrorType, key, err.Error())
+		}
+		history = append(history, newKeyModification(modification))
+	}
+
+	return history, nil
+}
+
+func newKeyModification(modification *queryresult.KeyModification) model.KeyModification {
+	timestamp := ""
+	if ts := modification.GetTimestamp(); ts != nil {
+		timestamp = time.Unix(ts.GetSeconds(), int64(ts.GetNanos())).UTC().Format(time.RFC3339Nano)
+	}
+
+	return model.KeyModification{
+		TxID:      modification.GetTxId(),
+		Timestamp: timestamp,
+		IsDelete:  modification.GetIsDelete(),
+		Value:     string(modification.GetValue()),
+	}
+}
@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// LockEscrow returns the escrow name of lock id (counterparty of its transfers)
+func LockEscrow(id string) string {
+	return lockCompositeKey + "/" + id
+}
+
+// SaveLock saves new lock - lock/{recipient}/{id}
+// & adds its amount to the locked amount of recipient
+func SaveLock(stub shim.ChaincodeStubInterface, lock *model.TokenLock) error {
+	lockKey, err := stub.CreateCompositeKey(lockCompositeKey, []string{lock.Recipient, lock.ID})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, lockCompositeKey, err.Error())
+	}
+
+	lockBytes, err := json.Marshal(lock)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, "tokenLock", err.Error())
+	}
+
+	err = stub.PutState(lockKey, lockBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, lockKey, err.Error())
+	}
+
+	lockedAmount, err := GetLockedAmount(stub, lock.Recipient)
+	if err != nil {
+		return err
+	}
+	return saveLockedAmount(stub, lock.Recipient, lockedAmount.Add(lockedAmount, lock.Amount.Int()))
+}
+
+// GetLock returns the lock id of recipient
+func GetLock(stub shim.ChaincodeStubInterface, recipient, id string) (*model.TokenLock, error) {
+	lockKey, err := stub.CreateCompositeKey(lockCompositeKey, []string{recipient, id})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, lockCompositeKey, err.Error())
+	}
+
+	lockBytes, err := stub.GetState(lockKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, lockKey, err.Error())
+	}
+	if lockBytes == nil {
+		return nil, errors.New("lock " + id + " of " + recipient + " doesn't exist")
+	}
+
+	lock := model.TokenLock{}
+	err = json.Unmarshal(lockBytes, &lock)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, "tokenLock", err.Error())
+	}
+
+	return &lock, nil
+}
+
+// ClaimLocks deletes locks of recipient & credits their amounts to recipient
+// the amounts are credited together (see CreditBalance)
+// records the activity & emits transfer event per lock
+// Returns the claimed amount
+func ClaimLocks(stub shim.ChaincodeStubInterface, recipient string, locks []*model.TokenLock) (*big.Int, error) {
+	// delete locks & sum their amounts
+	claimed := big.NewInt(0)
+	for _, lock := range locks {
+		lockKey, err := stub.CreateCompositeKey(lockCompositeKey, []string{recipient, lock.ID})
+		if err != nil {
+			return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, lockCompositeKey, err.Error())
+		}
+		err = stub.DelState(lockKey)
+		if err != nil {
+			return nil, model.NewCustomError(model.DelStateErrorType, lockKey, err.Error())
+		}
+		claimed.Add(claimed, lock.Amount.Int())
+	}
+
+	// subtract claimed amount from the locked amount
+	lockedAmount, err := GetLockedAmount(stub, recipient)
+	if err != nil {
+		return nil, err
+	}
+	if lockedAmount.Cmp(claimed) < 0 {
+		return nil, errors.New("locked amount of " + recipient + " is less than the claimed amount")
+	}
+	err = saveLockedAmount(stub, recipient, lockedAmount.Sub(lockedAmount, claimed))
+	if err != nil {
+		return nil, err
+	}
+
+	// credit recipient once
+	err = CreditBalance(stub, recipient, claimed)
+	if err != nil {
+		return nil, err
+	}
+
+	// record activity & emit transfer event per lock
+	for i, lock := range locks {
+		err = RecordActivity(stub, recipient, LockEscrow(lock.ID), model.ActivityIn, lock.Amount.Int(), "lock claim", i)
+		if err != nil {
+			return nil, err
+		}
+		err = EmitTransferEvent(stub, LockEscrow(lock.ID), recipient, lock.Amount.Int())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return claimed, nil
+}
+
+// GetLockedAmount returns the sum of the pending locks of recipient - lockedAmount/{recipient}
+// (the sum is saved, so balance queries don't read every lock)
+func GetLockedAmount(stub shim.ChaincodeStubInterface, recipient string) (*big.Int, error) {
+	lockedKey, err := stub.CreateCompositeKey(lockedAmountCompositeKey, []string{recipient})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, lockedAmountCompositeKey, err.Error())
+	}
+
+	lockedBytes, err := stub.GetState(lockedKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, lockedKey, err.Error())
+	}
+	if lockedBytes == nil {
+		return big.NewInt(0), nil
+	}
+
+	lockedAmount, ok := new(big.Int).SetString(string(lockedBytes), 10)
+	if !ok {
+		return nil, model.NewCustomError(model.ConvertErrorType, lockedKey, "locked amount is not integer")
+	}
+
+	return lockedAmount, nil
+}
+
+// saveLockedAmount saves the locked amount of recipient (deletes the key when zero)
+func saveLockedAmount(stub shim.ChaincodeStubInterface, recipient string, lockedAmount *big.Int) error {
+	lockedKey, err := stub.CreateCompositeKey(lockedAmountCompositeKey, []string{recipient})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, lockedAmountCompositeKey, err.Error())
+	}
+
+	if lockedAmount.Sign() == 0 {
+		err = stub.DelState(lockedKey)
+		if err != nil {
+			return model.NewCustomError(model.DelStateErrorType, lockedKey, err.Error())
+		}
+		return nil
+	}
+
+	err = stub.PutState(lockedKey, []byte(lockedAmount.String()))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, lockedKey, err.Error())
+	}
+	return nil
+}
+
+// GetLockPage returns one page of the pending locks of recipient & the bookmark of next page
+func GetLockPage(stub shim.ChaincodeStubInterface, recipient string, pageSize int32, bookmark string) ([]*model.TokenLock, string, error) {
+	lockIterator, metadata, err := stub.GetStateByPartialCompositeKeyWithPagination(lockCompositeKey, []string{recipient}, pageSize, bookmark)
+	if err != nil {
+		return nil, "", model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, lockCompositeKey, err.Error())
+	}
+	if lockIterator == nil {
+		return nil, "", model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, lockCompositeKey, "pagination is not supported")
+	}
+	defer lockIterator.Close()
+
+	locks := []*model.TokenLock{}
+	for lockIterator.HasNext() {
+		lockKV, err := lockIterator.Next()
+		if err != nil {
+			return nil, "", model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, lockCompositeKey, err.Error())
+		}
+
+		lock := model.TokenLock{}
+		err = json.Unmarshal(lockKV.GetValue(), &lock)
+		if err != nil {
+			return nil, "", model.NewCustomError(model.UnMarshalErrorType, "tokenLock", err.Error())
+		}
+		locks = append(locks, &lock)
+	}
+
+	nextBookmark := ""
+	if metadata != nil && len(locks) == int(pageSize) {
+		nextBookmark = metadata.GetBookmark()
+	}
+
+	return locks, nextBookmark, nil
+}
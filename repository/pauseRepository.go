@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func SavePaused(stub shim.ChaincodeStubInterface, paused bool) error {
+	// save pause state - erc20Paused
+	key, err := pausedKey(stub)
+	if err != nil {
+		return err
+	}
+
+	err = stub.PutState(key, []byte(strconv.FormatBool(paused)))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, pausedCompositeKey, err.Error())
+	}
+
+	return nil
+}
+
+func IsPaused(stub shim.ChaincodeStubInterface) (bool, error) {
+	key, err := pausedKey(stub)
+	if err != nil {
+		return false, err
+	}
+
+	pausedBytes, err := stub.GetState(key)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, pausedCompositeKey, err.Error())
+	}
+
+	// token is not paused until pause is called
+	if pausedBytes == nil {
+		return false, nil
+	}
+
+	paused, err := strconv.ParseBool(string(pausedBytes))
+	if err != nil {
+		return false, model.NewCustomError(model.ConvertErrorType, pausedCompositeKey, err.Error())
+	}
+
+	return paused, nil
+}
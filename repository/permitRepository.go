@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// SavePermitKey saves PEM encoded public key of address which verifies permit signatures
+func SavePermitKey(stub shim.ChaincodeStubInterface, address string, publicKeyPEM []byte) error {
+	// create composite key - permitKey/{address}
+	key, err := stub.CreateCompositeKey(permitKeyCompositeKey, []string{address})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, permitKeyCompositeKey, err.Error())
+	}
+
+	err = stub.PutState(key, publicKeyPEM)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, permitKeyCompositeKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetPermitKey returns PEM encoded public key of address (nil if not registered)
+func GetPermitKey(stub shim.ChaincodeStubInterface, address string) ([]byte, error) {
+	key, err := stub.CreateCompositeKey(permitKeyCompositeKey, []string{address})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, permitKeyCompositeKey, err.Error())
+	}
+
+	publicKeyPEM, err := stub.GetState(key)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, permitKeyCompositeKey, err.Error())
+	}
+
+	return publicKeyPEM, nil
+}
+
+// SaveNonce saves the next permit nonce of owner
+func SaveNonce(stub shim.ChaincodeStubInterface, owner string, nonce uint64) error {
+	// create composite key - permitNonce/{owner}
+	key, err := stub.CreateCompositeKey(permitNonceCompositeKey, []string{owner})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, permitNonceCompositeKey, err.Error())
+	}
+
+	err = stub.PutState(key, []byte(strconv.FormatUint(nonce, 10)))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, permitNonceCompositeKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetNonce returns the next permit nonce of owner (0 if owner never used permit)
+func GetNonce(stub shim.ChaincodeStubInterface, owner string) (uint64, error) {
+	key, err := stub.CreateCompositeKey(permitNonceCompositeKey, []string{owner})
+	if err != nil {
+		return 0, model.NewCustomError(model.CreateCompositeKeyErrorType, permitNonceCompositeKey, err.Error())
+	}
+
+	nonceBytes, err := stub.GetState(key)
+	if err != nil {
+		return 0, model.NewCustomError(model.GetStateErrorType, permitNonceCompositeKey, err.Error())
+	}
+	if nonceBytes == nil {
+		return 0, nil
+	}
+
+	nonce, err := strconv.ParseUint(string(nonceBytes), 10, 64)
+	if err != nil {
+		return 0, model.NewCustomError(model.ConvertErrorType, permitNonceCompositeKey, err.Error())
+	}
+
+	return nonce, nil
+}
+
+// SavePermitDomain saves domain which binds permit signatures to this deployment
+func SavePermitDomain(stub shim.ChaincodeStubInterface, domain string) error {
+	// create composite key - permitDomain
+	key, err := stub.CreateCompositeKey(permitDomainCompositeKey, []string{})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, permitDomainCompositeKey, err.Error())
+	}
+
+	err = stub.PutState(key, []byte(domain))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, permitDomainCompositeKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetPermitDomain returns domain of permit signatures (empty if not saved)
+func GetPermitDomain(stub shim.ChaincodeStubInterface) (string, error) {
+	key, err := stub.CreateCompositeKey(permitDomainCompositeKey, []string{})
+	if err != nil {
+		return "", model.NewCustomError(model.CreateCompositeKeyErrorType, permitDomainCompositeKey, err.Error())
+	}
+
+	domainBytes, err := stub.GetState(key)
+	if err != nil {
+		return "", model.NewCustomError(model.GetStateErrorType, permitDomainCompositeKey, err.Error())
+	}
+
+	return string(domainBytes), nil
+}
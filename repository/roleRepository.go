@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+func SaveRole(stub shim.ChaincodeStubInterface, role, address string) error {
+	// create composite key for role - role/{role}/{address}
+	roleKey, err := stub.CreateCompositeKey(roleCompositeKey, []string{role, address})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, roleCompositeKey, err.Error())
+	}
+
+	err = stub.PutState(roleKey, existsValue)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, roleKey, err.Error())
+	}
+
+	return nil
+}
+
+func DeleteRole(stub shim.ChaincodeStubInterface, role, address string) error {
+	roleKey, err := stub.CreateCompositeKey(roleCompositeKey, []string{role, address})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, roleCompositeKey, err.Error())
+	}
+
+	err = stub.DelState(roleKey)
+	if err != nil {
+		return model.NewCustomError(model.DelStateErrorType, roleKey, err.Error())
+	}
+
+	return nil
+}
+
+func HasRole(stub shim.ChaincodeStubInterface, role, address string) (bool, error) {
+	roleKey, err := stub.CreateCompositeKey(roleCompositeKey, []string{role, address})
+	if err != nil {
+		return false, model.NewCustomError(model.CreateCompositeKeyErrorType, roleCompositeKey, err.Error())
+	}
+
+	roleBytes, err := stub.GetState(roleKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, roleKey, err.Error())
+	}
+
+	return roleBytes != nil, nil
+}
+
+func GetRoleMembers(stub shim.ChaincodeStubInterface, role string) ([]string, error) {
+	// get all members of role (format is iterator)
+	roleIterator, err := stub.GetStateByPartialCompositeKey(roleCompositeKey, []string{role})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, roleCompositeKey, err.Error())
+	}
+	defer roleIterator.Close()
+
+	members := []string{}
+	for roleIterator.HasNext() {
+		roleKV, err := roleIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, roleCompositeKey, err.Error())
+		}
+
+		// get member address
+		_, attributes, err := stub.SplitCompositeKey(roleKV.GetKey())
+		if err != nil {
+			return nil, model.NewCustomError(model.SpliteCompositeKeyErrorType, roleKV.GetKey(), err.Error())
+		}
+		members = append(members, attributes[1])
+	}
+
+	return members, nil
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// SaveSnapshotID saves the current snapshot id
+func SaveSnapshotID(stub shim.ChaincodeStubInterface, id uint64) error {
+	// save snapshot id - snapshotId
+	key, err := stub.CreateCompositeKey(snapshotIDCompositeKey, []string{})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, snapshotIDCompositeKey, err.Error())
+	}
+
+	err = stub.PutState(key, []byte(strconv.FormatUint(id, 10)))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, snapshotIDCompositeKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetSnapshotID returns the current snapshot id (0 until the first snapshot)
+func GetSnapshotID(stub shim.ChaincodeStubInterface) (uint64, error) {
+	key, err := stub.CreateCompositeKey(snapshotIDCompositeKey, []string{})
+	if err != nil {
+		return 0, model.NewCustomError(model.CreateCompositeKeyErrorType, snapshotIDCompositeKey, err.Error())
+	}
+
+	idBytes, err := stub.GetState(key)
+	if err != nil {
+		return 0, model.NewCustomError(model.GetStateErrorType, snapshotIDCompositeKey, err.Error())
+	}
+	if idBytes == nil {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(string(idBytes), 10, 64)
+	if err != nil {
+		return 0, model.NewCustomError(model.ConvertErrorType, snapshotIDCompositeKey, err.Error())
+	}
+
+	return id, nil
+}
+
+// snapshotBalance saves the balance of owner before the first change after the current snapshot
+// to snapshotBalance/{id}/{owner}
+func snapshotBalance(stub shim.ChaincodeStubInterface, owner string) error {
+	return snapshotValue(stub, snapshotBalanceCompositeKey, owner, func() (string, bool, error) {
+		balance, err := GetBalance(stub, owner, true)
+		if err != nil {
+			return "", false, err
+		}
+		return balance.String(), true, nil
+	})
+}
+
+// snapshotTotalSupply saves the total supply of token before the first change after the current snapshot
+// to snapshotTotalSupply/{id}/{tokenName}
+func snapshotTotalSupply(stub shim.ChaincodeStubInterface, tokenName string) error {
+	return snapshotValue(stub, snapshotTotalSupplyCompositeKey, tokenName, func() (string, bool, error) {
+		erc20Key, err := metadataKey(stub, tokenName)
+		if err != nil {
+			return "", false, err
+		}
+		erc20Bytes, err := stub.GetState(erc20Key)
+		if err != nil {
+			return "", false, model.NewCustomError(model.GetStateErrorType, "erc20Metadata", err.Error())
+		}
+
+		// token doesn't exist yet
+		if erc20Bytes == nil {
+			return "", false, nil
+		}
+
+		erc20 := model.ERC20Metadata{}
+		err = json.Unmarshal(erc20Bytes, &erc20)
+		if err != nil {
+			return "", false, model.NewCustomError(model.UnMarshalErrorType, "erc20Metadata", err.Error())
+		}
+		return erc20.GetTotalSupply().String(), true, nil
+	})
+}
+
+// snapshotValue saves the value of getValue to objectType/{id}/{name}
+// if there is a snapshot & the value is not saved for it yet
+func snapshotValue(stub shim.ChaincodeStubInterface, objectType, name string, getValue func() (string, bool, error)) error {
+	id, err := GetSnapshotID(stub)
+	if err != nil || id == 0 {
+		return err
+	}
+
+	key, err := stub.CreateCompositeKey(objectType, []string{strconv.FormatUint(id, 10), name})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, objectType, err.Error())
+	}
+	savedBytes, err := stub.GetState(key)
+	if err != nil {
+		return model.NewCustomError(model.GetStateErrorType, key, err.Error())
+	}
+	if savedBytes != nil {
+		return nil
+	}
+
+	value, ok, err := getValue()
+	if err != nil || !ok {
+		return err
+	}
+
+	err = stub.PutState(key, []byte(value))
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, key, err.Error())
+	}
+
+	return nil
+}
+
+// GetBalanceAtSnapshot returns the balance of owner at snapshot id
+func GetBalanceAtSnapshot(stub shim.ChaincodeStubInterface, owner string, id uint64) (*model.HistoricalValue, error) {
+	value, found, err := getSnapshotValue(stub, snapshotBalanceCompositeKey, owner, id)
+	if err != nil || found {
+		return &model.HistoricalValue{Value: value}, err
+	}
+
+	// balance is not changed since snapshot id
+	balance, err := GetBalance(stub, owner, true)
+	if err != nil {
+		return nil, err
+	}
+	return &model.HistoricalValue{Value: balance.String()}, nil
+}
+
+// GetTotalSupplyAtSnapshot returns the total supply of token at snapshot id
+func GetTotalSupplyAtSnapshot(stub shim.ChaincodeStubInterface, tokenName string, id uint64) (*model.HistoricalValue, error) {
+	value, found, err := getSnapshotValue(stub, snapshotTotalSupplyCompositeKey, tokenName, id)
+	if err != nil || found {
+		return &model.HistoricalValue{Value: value}, err
+	}
+
+	// total supply is not changed since snapshot id
+	totalSupply, err := GetERC20TotalSupply(stub, tokenName)
+	if err != nil {
+		return nil, err
+	}
+	return &model.HistoricalValue{Value: totalSupply.String()}, nil
+}
+
+// getSnapshotValue returns the value saved at the first change after snapshot id
+// (the value at snapshot id is kept until the next change)
+func getSnapshotValue(stub shim.ChaincodeStubInterface, objectType, name string, id uint64) (string, bool, error) {
+	currentID, err := GetSnapshotID(stub)
+	if err != nil {
+		return "", false, err
+	}
+	if id == 0 || id > currentID {
+		return "", false, errors.New("snapshot " + strconv.FormatUint(id, 10) + " doesn't exist")
+	}
+
+	for snapshotID := id; snapshotID <= currentID; snapshotID++ {
+		key, err := stub.CreateCompositeKey(objectType, []string{strconv.FormatUint(snapshotID, 10), name})
+		if err != nil {
+			return "", false, model.NewCustomError(model.CreateCompositeKeyErrorType, objectType, err.Error())
+		}
+		valueBytes, err := stub.GetState(key)
+		if err != nil {
+			return "", false, model.NewCustomError(model.GetStateErrorType, key, err.Error())
+		}
+		if valueBytes != nil {
+			return string(valueBytes), true, nil
+		}
+	}
+
+	return "", false, nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// SaveSwapOffer saves offer - swapOffer/{counterparty}/{taker}
+// (counterparty has at most one offer to each taker)
+func SaveSwapOffer(stub shim.ChaincodeStubInterface, offer *model.SwapOffer) error {
+	offerKey, err := stub.CreateCompositeKey(swapOfferCompositeKey, []string{offer.Counterparty, offer.Taker})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, swapOfferCompositeKey, err.Error())
+	}
+
+	offerBytes, err := json.Marshal(offer)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, "swapOffer", err.Error())
+	}
+
+	err = stub.PutState(offerKey, offerBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, offerKey, err.Error())
+	}
+
+	return nil
+}
+
+// DeleteSwapOffer deletes the offer of counterparty to taker
+func DeleteSwapOffer(stub shim.ChaincodeStubInterface, counterparty, taker string) error {
+	offerKey, err := stub.CreateCompositeKey(swapOfferCompositeKey, []string{counterparty, taker})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, swapOfferCompositeKey, err.Error())
+	}
+
+	err = stub.DelState(offerKey)
+	if err != nil {
+		return model.NewCustomError(model.DelStateErrorType, offerKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetSwapOffer returns the offer of counterparty to taker (nil if counterparty has no offer to taker)
+func GetSwapOffer(stub shim.ChaincodeStubInterface, counterparty, taker string) (*model.SwapOffer, error) {
+	offerKey, err := stub.CreateCompositeKey(swapOfferCompositeKey, []string{counterparty, taker})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, swapOfferCompositeKey, err.Error())
+	}
+
+	offerBytes, err := stub.GetState(offerKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, offerKey, err.Error())
+	}
+	if offerBytes == nil {
+		return nil, nil
+	}
+
+	offer := model.SwapOffer{}
+	err = json.Unmarshal(offerBytes, &offer)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, "swapOffer", err.Error())
+	}
+
+	return &offer, nil
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/erc20/model"
+	"github.com/erc20/util"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// Transfer moves amount token from sender to recipient, records the activity with memo
+// & emits transfer event
+func Transfer(stub shim.ChaincodeStubInterface, sender, recipient string, amount *big.Int, memo string) error {
+	// get sender's balance
+	senderBalance, err := GetBalance(stub, sender, true)
+	if err != nil {
+		return err
+	}
+
+	// calculate sender's balance (balance cannot be negative)
+	senderResult, err := util.Sub(senderBalance, amount)
+	if err != nil {
+		return errors.New("sender's balance is not sufficient")
+	}
+
+	// balance is not changed when sender is recipient
+	if sender != recipient {
+		// save the sender's balance & credit the recipient
+		err = SaveBalance(stub, sender, senderResult)
+		if err != nil {
+			return err
+		}
+		err = CreditBalance(stub, recipient, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	// record activity of sender & recipient
+	err = RecordTransferActivity(stub, sender, recipient, amount, memo, 0)
+	if err != nil {
+		return err
+	}
+
+	// emit transfer event
+	return EmitTransferEvent(stub, sender, recipient, amount)
+}
+
+// Escrow moves amount token from sender into the escrow record named escrow (e.g. vesting/{id})
+// the record itself holds the tokens until they are paid out by ReleaseEscrow
+// records the activity of sender with memo & emits transfer event to escrow
+func Escrow(stub shim.ChaincodeStubInterface, sender, escrow string, amount *big.Int, memo string) error {
+	// get sender's balance
+	senderBalance, err := GetBalance(stub, sender, true)
+	if err != nil {
+		return err
+	}
+
+	// calculate & save sender's balance (balance cannot be negative)
+	senderResult, err := util.Sub(senderBalance, amount)
+	if err != nil {
+		return errors.New("sender's balance is not sufficient")
+	}
+	err = SaveBalance(stub, sender, senderResult)
+	if err != nil {
+		return err
+	}
+
+	// record activity of sender
+	err = RecordActivity(stub, sender, escrow, model.ActivityOut, amount, memo, 0)
+	if err != nil {
+		return err
+	}
+
+	// emit transfer event
+	return EmitTransferEvent(stub, sender, escrow, amount)
+}
+
+// ReleaseEscrow credits amount token held by the escrow record named escrow to recipient
+// the caller must deduct amount from the record
+// records the activity of recipient with memo & emits transfer event from escrow
+func ReleaseEscrow(stub shim.ChaincodeStubInterface, escrow, recipient string, amount *big.Int, memo string) error {
+	// credit recipient
+	err := CreditBalance(stub, recipient, amount)
+	if err != nil {
+		return err
+	}
+
+	// record activity of recipient
+	err = RecordActivity(stub, recipient, escrow, model.ActivityIn, amount, memo, 0)
+	if err != nil {
+		return err
+	}
+
+	// emit transfer event
+	return EmitTransferEvent(stub, escrow, recipient, amount)
+}
+
+// TransferFrom moves amount token from owner to recipient using allowance of spender
+// & emits transfer and approval events
+// nothing is saved if allowance or balance is not sufficient
+func TransferFrom(stub shim.ChaincodeStubInterface, owner, spender, recipient string, amount *big.Int) error {
+	// get allowance (expired allowance is zero)
+	allowance, err := GetAllowanceRecord(stub, owner, spender)
+	if err != nil {
+		return err
+	}
+
+	// calculate allowance (allowance cannot be negative, but can be zero)
+	allowanceResult, err := util.Sub(allowance.Allowance.Int(), amount)
+	if err != nil {
+		return errors.New("spender's allowance is not sufficient")
+	}
+
+	// move balance
+	err = Transfer(stub, owner, recipient, amount, "")
+	if err != nil {
+		return err
+	}
+
+	// decrease allowance (expiry is not changed)
+	err = SaveAllowance(stub, owner, spender, allowanceResult, allowance.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	// emit approval event
+	return EmitApprovalEvent(stub, owner, spender, allowanceResult)
+}
+
+// BatchTransfer moves amounts token from sender to every recipient & emits transfer event per leg
+// sender's balance is debited once, and the amounts to the same recipient are credited together (see CreditBalance)
+func BatchTransfer(stub shim.ChaincodeStubInterface, sender string, recipients []string, amounts []*big.Int) error {
+	// sum amounts by recipient
+	total := big.NewInt(0)
+	credits := map[string]*big.Int{}
+	creditOrder := []string{}
+	for i, recipient := range recipients {
+		if recipient == sender {
+			return errors.New("sender cannot be recipient of batch transfer")
+		}
+
+		var err error
+		total, err = util.Add(total, amounts[i])
+		if err != nil {
+			return errors.New("total amount " + err.Error())
+		}
+
+		if _, ok := credits[recipient]; !ok {
+			credits[recipient] = big.NewInt(0)
+			creditOrder = append(creditOrder, recipient)
+		}
+		credits[recipient].Add(credits[recipient], amounts[i])
+	}
+
+	// debit sender once
+	senderBalance, err := GetBalance(stub, sender, true)
+	if err != nil {
+		return err
+	}
+	senderResult, err := util.Sub(senderBalance, total)
+	if err != nil {
+		return errors.New("sender's balance is not sufficient")
+	}
+	err = SaveBalance(stub, sender, senderResult)
+	if err != nil {
+		return err
+	}
+
+	// credit every recipient
+	for _, recipient := range creditOrder {
+		err = CreditBalance(stub, recipient, credits[recipient])
+		if err != nil {
+			return err
+		}
+	}
+
+	// record activity & emit transfer event per leg
+	for i, recipient := range recipients {
+		err = RecordTransferActivity(stub, sender, recipient, amounts[i], "", i)
+		if err != nil {
+			return err
+		}
+		err = EmitTransferEvent(stub, sender, recipient, amounts[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// SaveTrustedChaincode adds chaincode to trusted chaincodes or removes it
+// - trustedChaincode/{name}
+func SaveTrustedChaincode(stub shim.ChaincodeStubInterface, name string, trusted bool) error {
+	trustedKey, err := stub.CreateCompositeKey(trustedChaincodeCompositeKey, []string{name})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, trustedChaincodeCompositeKey, err.Error())
+	}
+
+	if trusted {
+		err = stub.PutState(trustedKey, existsValue)
+		if err != nil {
+			return model.NewCustomError(model.PutStateErrorType, trustedKey, err.Error())
+		}
+		return nil
+	}
+
+	err = stub.DelState(trustedKey)
+	if err != nil {
+		return model.NewCustomError(model.DelStateErrorType, trustedKey, err.Error())
+	}
+	return nil
+}
+
+// IsTrustedChaincode returns true if chaincode is trusted
+func IsTrustedChaincode(stub shim.ChaincodeStubInterface, name string) (bool, error) {
+	trustedKey, err := stub.CreateCompositeKey(trustedChaincodeCompositeKey, []string{name})
+	if err != nil {
+		return false, model.NewCustomError(model.CreateCompositeKeyErrorType, trustedChaincodeCompositeKey, err.Error())
+	}
+
+	trustedBytes, err := stub.GetState(trustedKey)
+	if err != nil {
+		return false, model.NewCustomError(model.GetStateErrorType, trustedKey, err.Error())
+	}
+
+	return trustedBytes != nil, nil
+}
+
+// GetTrustedChaincodes returns every trusted chaincode
+func GetTrustedChaincodes(stub shim.ChaincodeStubInterface) ([]model.TrustedChaincode, error) {
+	trustedIterator, err := stub.GetStateByPartialCompositeKey(trustedChaincodeCompositeKey, []string{})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, trustedChaincodeCompositeKey, err.Error())
+	}
+	defer trustedIterator.Close()
+
+	trustedChaincodes := []model.TrustedChaincode{}
+	for trustedIterator.HasNext() {
+		trustedKV, err := trustedIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, trustedChaincodeCompositeKey, err.Error())
+		}
+
+		// get chaincode name
+		_, attributes, err := stub.SplitCompositeKey(trustedKV.GetKey())
+		if err != nil {
+			return nil, model.NewCustomError(model.SpliteCompositeKeyErrorType, trustedKV.GetKey(), err.Error())
+		}
+		trustedChaincodes = append(trustedChaincodes, model.TrustedChaincode{Name: attributes[0]})
+	}
+
+	return trustedChaincodes, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// VestingEscrow returns the escrow name of schedule id (counterparty of its transfers)
+func VestingEscrow(id string) string {
+	return vestingCompositeKey + "/" + id
+}
+
+// SaveVestingSchedule saves schedule - vesting/{beneficiary}/{id}
+func SaveVestingSchedule(stub shim.ChaincodeStubInterface, schedule *model.VestingSchedule) error {
+	scheduleKey, err := stub.CreateCompositeKey(vestingCompositeKey, []string{schedule.Beneficiary, schedule.ID})
+	if err != nil {
+		return model.NewCustomError(model.CreateCompositeKeyErrorType, vestingCompositeKey, err.Error())
+	}
+
+	scheduleBytes, err := json.Marshal(schedule)
+	if err != nil {
+		return model.NewCustomError(model.MarshalErrorType, "vestingSchedule", err.Error())
+	}
+
+	err = stub.PutState(scheduleKey, scheduleBytes)
+	if err != nil {
+		return model.NewCustomError(model.PutStateErrorType, scheduleKey, err.Error())
+	}
+
+	return nil
+}
+
+// GetVestingSchedule returns the schedule id of beneficiary
+func GetVestingSchedule(stub shim.ChaincodeStubInterface, beneficiary, id string) (*model.VestingSchedule, error) {
+	scheduleKey, err := stub.CreateCompositeKey(vestingCompositeKey, []string{beneficiary, id})
+	if err != nil {
+		return nil, model.NewCustomError(model.CreateCompositeKeyErrorType, vestingCompositeKey, err.Error())
+	}
+
+	scheduleBytes, err := stub.GetState(scheduleKey)
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStateErrorType, scheduleKey, err.Error())
+	}
+	if scheduleBytes == nil {
+		return nil, errors.New("vesting schedule " + id + " of " + beneficiary + " doesn't exist")
+	}
+
+	schedule := model.VestingSchedule{}
+	err = json.Unmarshal(scheduleBytes, &schedule)
+	if err != nil {
+		return nil, model.NewCustomError(model.UnMarshalErrorType, "vestingSchedule", err.Error())
+	}
+
+	return &schedule, nil
+}
+
+// GetVestingSchedules returns every schedule of beneficiary
+func GetVestingSchedules(stub shim.ChaincodeStubInterface, beneficiary string) ([]*model.VestingSchedule, error) {
+	scheduleIterator, err := stub.GetStateByPartialCompositeKey(vestingCompositeKey, []string{beneficiary})
+	if err != nil {
+		return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, vestingCompositeKey, err.Error())
+	}
+	defer scheduleIterator.Close()
+
+	schedules := []*model.VestingSchedule{}
+	for scheduleIterator.HasNext() {
+		scheduleKV, err := scheduleIterator.Next()
+		if err != nil {
+			return nil, model.NewCustomError(model.GetStatePartialCompositeKeyErrorType, vestingCompositeKey, err.Error())
+		}
+
+		schedule := model.VestingSchedule{}
+		err = json.Unmarshal(scheduleKV.GetValue(), &schedule)
+		if err != nil {
+			return nil, model.NewCustomError(model.UnMarshalErrorType, "vestingSchedule", err.Error())
+		}
+		schedules = append(schedules, &schedule)
+	}
+
+	return schedules, nil
+}
@@ -0,0 +1,17 @@
+package util
+
+import (
+	"github.com/erc20/model"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+)
+
+// GetTxTime returns the transaction timestamp in unix time (seconds)
+// every endorser gets the same value, so it can be used for time-based rules
+func GetTxTime(stub shim.ChaincodeStubInterface) (int64, error) {
+	txTimestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return 0, model.NewCustomError(model.GetTxTimestampErrorType, "txTimestamp", err.Error())
+	}
+
+	return txTimestamp.GetSeconds(), nil
+}
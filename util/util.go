@@ -1,19 +1,62 @@
 package util
 
 import (
-	"strconv"
+	"errors"
+	"math/big"
 
 	"github.com/erc20/model"
 )
 
-func ConvertToPositive(name, value string) (*int, error) {
-	intValue, err := strconv.Atoi(value)
-	if err != nil {
+// MaxAmount is the maximum amount of token (2^256 - 1, same as uint256 of ERC20)
+var MaxAmount = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// ConvertToAmount converts value to amount of token
+// amount must be integer between 0 and MaxAmount
+func ConvertToAmount(name, value string) (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok {
 		return nil, model.NewCustomError(model.ConvertErrorType, name, " must be integer")
 	}
-	if intValue <= 0 {
+	if amount.Sign() < 0 {
+		return nil, model.NewCustomError(model.ConvertErrorType, name, " cannot be negative")
+	}
+	if amount.Cmp(MaxAmount) > 0 {
+		return nil, model.NewCustomError(model.ConvertErrorType, name, " is too large")
+	}
+
+	return amount, nil
+}
+
+// ConvertToPositive converts value to amount of token
+// amount must be integer between 1 and MaxAmount
+func ConvertToPositive(name, value string) (*big.Int, error) {
+	amount, err := ConvertToAmount(name, value)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Sign() <= 0 {
 		return nil, model.NewCustomError(model.ConvertErrorType, name, " must be positive")
 	}
 
-	return &intValue, nil
+	return amount, nil
+}
+
+// Add returns a + b, or error if the result exceeds MaxAmount
+func Add(a, b *big.Int) (*big.Int, error) {
+	result := new(big.Int).Add(a, b)
+	if result.Cmp(MaxAmount) > 0 {
+		return nil, errors.New("amount overflow")
+	}
+
+	return result, nil
+}
+
+// Sub returns a - b, or error if the result is negative
+func Sub(a, b *big.Int) (*big.Int, error) {
+	result := new(big.Int).Sub(a, b)
+	if result.Sign() < 0 {
+		return nil, errors.New("amount underflow")
+	}
+
+	return result, nil
 }